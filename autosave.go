@@ -0,0 +1,213 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/andareed/siftly-hostlog/dialogs"
+	"github.com/andareed/siftly-hostlog/logging"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// snapshotBackups is how many earlier copies of a saved file are kept as
+// <name>.bak.1 (newest) to <name>.bak.N; 0 keeps none.
+var snapshotBackups = 3
+
+// autosaveInterval is how often the session is written to its autosave
+// file, from the config file or --autosave; 0 turns autosave off.
+var autosaveInterval time.Duration
+
+// confirmRecover identifies the startup dialog offering an autosave.
+const confirmRecover = "recover-autosave"
+
+// rotateBackups shifts the existing backups of path up by one and keeps the
+// current file as <path>.bak.1 before it is replaced.
+func rotateBackups(path string, keep int) error {
+	if keep <= 0 {
+		return nil
+	}
+	info, err := os.Stat(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	} else if err != nil {
+		return err
+	}
+	backup := func(n int) string { return fmt.Sprintf("%s.bak.%d", path, n) }
+	os.Remove(backup(keep))
+	for n := keep - 1; n >= 1; n-- {
+		if err := os.Rename(backup(n), backup(n+1)); err != nil && !errors.Is(err, fs.ErrNotExist) {
+			return err
+		}
+	}
+	// A hard link leaves path in place until the new file is renamed over it.
+	if err := os.Link(path, backup(1)); err == nil {
+		return nil
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(backup(1), data, info.Mode().Perm()); err != nil {
+		return err
+	}
+	return os.Chmod(backup(1), info.Mode().Perm())
+}
+
+// autosaveName is the hidden file beside path that a session opened from
+// path autosaves to, e.g. ".hostlog.csv.autosave.json".
+func autosaveName(path string) string {
+	initial := trimCompressionExt(path)
+	return filepath.Join(filepath.Dir(initial), "."+filepath.Base(initial)+".autosave.json")
+}
+
+type autosaveTickMsg struct{}
+
+// autosaveDoneMsg reports an autosave written in the background; state is
+// the sessionFingerprint it saved.
+type autosaveDoneMsg struct {
+	state uint64
+	err   error
+}
+
+// autosaveOffer is an autosave found at startup that is newer than the files
+// being opened.
+type autosaveOffer struct {
+	path    string
+	modTime time.Time
+}
+
+// checkAutosave sets up autosaving for a session read from inputs and looks
+// for an autosave left behind by an earlier one.
+func (m *model) checkAutosave(inputs []string) {
+	if len(inputs) == 0 || inputs[0] == stdinPath {
+		return
+	}
+	m.autosavePath = autosaveName(m.InitialPath)
+	info, err := os.Stat(m.autosavePath)
+	if err != nil {
+		return
+	}
+	for _, in := range inputs {
+		if src, err := os.Stat(in); err == nil && !info.ModTime().After(src.ModTime()) {
+			logging.Infof("checkAutosave: %s is older than %s, not offering it", m.autosavePath, in)
+			return
+		}
+	}
+	m.recovery = &autosaveOffer{path: m.autosavePath, modTime: info.ModTime()}
+}
+
+// offerRecovery asks whether to pick up the autosave found at startup.
+func (m *model) offerRecovery() {
+	msg := fmt.Sprintf("An autosave from %s is newer than the file you opened.\n\nRecover its marks, comments, sort order and time window?",
+		m.recovery.modTime.Format("2006-01-02 15:04:05"))
+	m.activeDialog = dialogs.NewConfirmDialog(confirmRecover, msg, []dialogs.ConfirmChoice{
+		{Key: "y", Label: "recover"},
+		{Key: "n", Label: "ignore"},
+	})
+	m.activeDialog.Show()
+}
+
+// recoverAutosave applies the state of the autosave offered at startup to
+// the rows just loaded.
+func (m *model) recoverAutosave() tea.Cmd {
+	path := m.recovery.path
+	m.recovery = nil
+	data, err := readInputFile(path)
+	if err != nil {
+		logging.Errorf("recoverAutosave: %v", err)
+		return m.startNotice(fmt.Sprintf("Recovery failed: %v", err), "error", noticeDuration)
+	}
+	var dto snapshotDTO
+	if err := json.Unmarshal(data, &dto); err != nil {
+		return m.startNotice(fmt.Sprintf("Recovery failed: %v", err), "error", noticeDuration)
+	}
+	if dto.Version != snapshotVersion {
+		return m.startNotice(fmt.Sprintf("Recovery failed: autosave version %d not supported", dto.Version), "error", noticeDuration)
+	}
+	if err := dto.restoreOnto(m); err != nil {
+		logging.Errorf("recoverAutosave: %v", err)
+		return m.startNotice(fmt.Sprintf("Recovery failed: %v", err), "error", noticeDuration)
+	}
+	m.InitialiseUI()
+	m.applyFilter()
+	if m.ready {
+		m.refreshView("recovered", true)
+	}
+	return m.startNotice(fmt.Sprintf("Recovered %d mark(s) and %d comment(s) from the autosave", len(m.data.markedRows), len(m.data.commentRows)), "success", noticeDuration)
+}
+
+// scheduleAutosave waits out the autosave interval.
+func (m *model) scheduleAutosave() tea.Cmd {
+	if autosaveInterval <= 0 || m.autosavePath == "" {
+		return nil
+	}
+	return tea.Tick(autosaveInterval, func(time.Time) tea.Msg { return autosaveTickMsg{} })
+}
+
+// autosave writes the session to its autosave file when it has changed since
+// the last autosave or save, unless rows are still loading, the autosave
+// from an earlier session has not been answered, or one is being written.
+// The state is taken here and encoded and written in the background.
+func (m *model) autosave() tea.Cmd {
+	next := m.scheduleAutosave()
+	state := m.sessionFingerprint()
+	if m.autosaving || m.load.busy() || m.recovery != nil || state == m.autosavedState {
+		return next
+	}
+	m.autosaving = true
+	path, encode := m.autosavePath, m.snapshotEncoder(m.autosavePath)
+	write := func() tea.Msg {
+		data, err := encode()
+		if err == nil {
+			err = writeOutputFile(path, data)
+		}
+		return autosaveDoneMsg{state: state, err: err}
+	}
+	return tea.Batch(write, next)
+}
+
+// autosaveDone records how a background autosave went.
+func (m *model) autosaveDone(msg autosaveDoneMsg) tea.Cmd {
+	m.autosaving = false
+	if msg.err != nil {
+		logging.Errorf("autosave: %v", msg.err)
+		return m.startNotice(fmt.Sprintf("Autosave failed: %v", msg.err), "error", noticeDuration)
+	}
+	m.autosavedState = msg.state
+	logging.Debugf("autosave: wrote %s", m.autosavePath)
+	if !m.dirty() {
+		// saved for real while the autosave was being written
+		m.clearAutosave()
+	}
+	return nil
+}
+
+// clearAutosave removes the autosave once the session has been saved for real.
+func (m *model) clearAutosave() {
+	if m.autosavePath == "" {
+		return
+	}
+	if err := os.Remove(m.autosavePath); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		logging.Warnf("clearAutosave: %v", err)
+	}
+}
+
+// parseAutosave reads the config file's autosave interval.
+func parseAutosave(s string) (time.Duration, error) {
+	if s == "" || s == "0" {
+		return 0, nil
+	}
+	d, err := time.ParseDuration(s)
+	if err != nil {
+		return 0, fmt.Errorf("invalid autosave interval %q: %w", s, err)
+	}
+	if d < 0 {
+		return 0, fmt.Errorf("invalid autosave interval %q", s)
+	}
+	return d, nil
+}
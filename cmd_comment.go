@@ -12,6 +12,7 @@ func (m *model) addComment(comment string) {
 
 	idx := m.data.filteredIndices[m.cursor]
 	hashId := m.data.rows[idx].id
+	m.recordEdit(editComment, idx, editValue{comment: m.data.commentRows[hashId]}, editValue{comment: comment})
 	if comment == "" {
 		delete(m.data.commentRows, hashId)
 		logging.Infof("Clear comment Index[%d] on HashID[%d]", idx, hashId)
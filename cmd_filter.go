@@ -7,27 +7,59 @@ import (
 	"github.com/andareed/siftly-hostlog/logging"
 )
 
+// setFilterPattern applies a filter: a case-insensitive regex over the whole
+// row, optionally combined with @Column<op>value terms (see columnPredicate).
 func (m *model) setFilterPattern(pattern string) error {
 	logging.Infof("Setting Pattern to: %s", pattern)
+	before := m.data.filterPattern
+	if err := m.compileFilter(pattern); err != nil {
+		return err
+	}
+	m.recordEdit(editFilter, m.cursorRowIndex(), editValue{filter: before}, editValue{filter: pattern})
+	m.applyFilter()
+	return nil
+}
+
+// compileFilter parses pattern into the filter without re-filtering the rows.
+func (m *model) compileFilter(pattern string) error {
 	if pattern == "" {
 		m.data.filterRegex = nil
+		m.data.filterPreds = nil
 		m.data.filterPattern = ""
 	} else {
-		compilePattern := pattern
-		if !strings.HasPrefix(pattern, "(?i)") && !strings.HasPrefix(pattern, "(?-i)") {
-			compilePattern = "(?i)" + pattern
-		}
-		re, err := regexp.Compile(compilePattern)
+		text, preds, err := m.splitFilterPattern(pattern)
 		if err != nil {
 			return err
 		}
+		var re *regexp.Regexp
+		if text != "" {
+			compilePattern := text
+			if !strings.HasPrefix(text, "(?i)") && !strings.HasPrefix(text, "(?-i)") {
+				compilePattern = "(?i)" + text
+			}
+			re, err = regexp.Compile(compilePattern)
+			if err != nil {
+				return err
+			}
+		}
 		m.data.filterRegex = re
+		m.data.filterPreds = preds
 		m.data.filterPattern = pattern
 	}
-	m.applyFilter()
 	return nil
 }
 
+// recompileFilter parses the active filter again after a column's type has
+// changed, so its @column values are typed the way the cells now are.
+func (m *model) recompileFilter() {
+	if m.data.filterPattern == "" {
+		return
+	}
+	if err := m.compileFilter(m.data.filterPattern); err != nil {
+		logging.Warnf("recompileFilter: keeping %q as it was compiled: %v", m.data.filterPattern, err)
+	}
+}
+
 // region Filtering
 
 func (m *model) includeRow(row renderedRow, rowIndex int) bool {
@@ -56,5 +88,11 @@ func (m *model) includeRow(row renderedRow, rowIndex int) bool {
 			return false
 		}
 	}
+
+	for _, p := range m.data.filterPreds {
+		if !p.matches(m, row) {
+			return false
+		}
+	}
 	return true
 }
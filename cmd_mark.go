@@ -14,6 +14,7 @@ func (m *model) markCurrent(colour MarkColor) {
 	}
 	master := m.data.filteredIndices[m.cursor] // Gets the row
 	id := m.data.rows[master].id
+	m.recordEdit(editMark, master, editValue{mark: m.data.markedRows[id]}, editValue{mark: colour})
 	if colour == MarkNone {
 		delete(m.data.markedRows, id)
 		logging.Infof("Cursor: %d with Stable ID %d has been unmarked", m.cursor, id)
@@ -0,0 +1,82 @@
+package main
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+
+	"github.com/andareed/siftly-hostlog/logging"
+)
+
+// defaultMetaName is where :savemeta and :loadmeta go without a path,
+// e.g. hostlog.meta.json next to hostlog.csv.
+func defaultMetaName(m model) string {
+	initial := trimCompressionExt(m.InitialPath)
+	if initial == "" || initial == stdinName {
+		return "annotations.meta.json"
+	}
+	return strings.TrimSuffix(initial, filepath.Ext(initial)) + ".meta.json"
+}
+
+// metaPath picks the path argument of :savemeta / :loadmeta or the default.
+func (m *model) metaPath(args []string) string {
+	if len(args) > 0 {
+		return args[0]
+	}
+	return defaultMetaName(*m)
+}
+
+// saveMeta writes the session's marks and comments alone to path.
+func (m *model) saveMeta(path string) tea.Cmd {
+	if err := SaveMeta(m, path); err != nil {
+		logging.Errorf("saveMeta: %v", err)
+		return m.startNotice(fmt.Sprintf("Saving annotations failed: %v", err), "error", noticeDuration)
+	}
+	return m.startNotice(fmt.Sprintf("Saved %d mark(s) and %d comment(s) to %s", len(m.data.markedRows), len(m.data.commentRows), path), "success", noticeDuration)
+}
+
+// loadMeta merges an annotations file into the session.
+func (m *model) loadMeta(path string) tea.Cmd {
+	msg, kind := m.mergeMetaFile(path)
+	m.applyFilter()
+	m.refreshView("load-meta", false)
+	return m.startNotice(msg, kind, noticeDuration)
+}
+
+// mergeMetaFile merges an annotations file into the rows loaded so far and
+// describes the outcome for a notice. While rows are still streaming in, the
+// rest are matched as they arrive and the outcome waits for the load to end.
+func (m *model) mergeMetaFile(path string) (msg, kind string) {
+	f, err := readMetaFile(path, m.data.header)
+	if err != nil {
+		logging.Errorf("mergeMetaFile: %v", err)
+		return fmt.Sprintf("Loading annotations failed: %v", err), "error"
+	}
+	f.apply(m, m.data.rows)
+	if m.load != nil && m.load.active {
+		m.pendingMeta = f
+		return fmt.Sprintf("Applying %s as rows load", filepath.Base(path)), "info"
+	}
+	return describeMetaMerge(path, f.result())
+}
+
+func describeMetaMerge(path string, res metaMerge) (msg, kind string) {
+	logging.Infof("describeMetaMerge: %s: %s", path, res)
+	kind = "success"
+	if res.Skipped > 0 || res.Conflicting > 0 {
+		kind = "warn"
+	}
+	return fmt.Sprintf("%s: %s", filepath.Base(path), res), kind
+}
+
+// pendingMetaSummary is the outcome of an annotations file merged while the
+// rows loaded, to tack onto the load's closing notice.
+func (m *model) pendingMetaSummary() (note string, warn bool) {
+	if m.pendingMeta == nil {
+		return "", false
+	}
+	res := m.pendingMeta.result()
+	return "; annotations " + res.String(), res.Skipped > 0 || res.Conflicting > 0
+}
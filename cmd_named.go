@@ -0,0 +1,112 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// namedCommand is a word command typed at the ":" prompt, e.g. ":type Status int".
+type namedCommand struct {
+	usage string
+	args  int // number of arguments expected, -1 for any
+	run   func(m *model, args []string) tea.Cmd
+}
+
+var namedCommands = map[string]namedCommand{
+	"type": {
+		usage: "type <column> <text|timestamp|int|float|ipv4|ipv6|mac|auto>",
+		args:  2,
+		run: func(m *model, args []string) tea.Cmd {
+			if err := m.setColumnType(args[0], args[1]); err != nil {
+				return m.startNotice(err.Error(), "warn", noticeDuration)
+			}
+			col := m.data.header[findColumnIndex(m.data.header, args[0])]
+			m.refreshView("column-type", true)
+			return m.startNotice(fmt.Sprintf("%s is now %s", col.Name, col.Type), "success", noticeDuration)
+		},
+	},
+	"savemeta": {
+		usage: "savemeta [file]",
+		args:  -1,
+		run: func(m *model, args []string) tea.Cmd {
+			if len(args) > 1 {
+				return m.startNotice("Usage: savemeta [file]", "warn", noticeDuration)
+			}
+			return m.saveMeta(m.metaPath(args))
+		},
+	},
+	"loadmeta": {
+		usage: "loadmeta [file]",
+		args:  -1,
+		run: func(m *model, args []string) tea.Cmd {
+			if len(args) > 1 {
+				return m.startNotice("Usage: loadmeta [file]", "warn", noticeDuration)
+			}
+			return m.loadMeta(m.metaPath(args))
+		},
+	},
+	"report": {
+		usage: "report [file.md|file.html]",
+		args:  -1,
+		run: func(m *model, args []string) tea.Cmd {
+			if len(args) > 1 {
+				return m.startNotice("Usage: report [file.md|file.html]", "warn", noticeDuration)
+			}
+			path := defaultReportName(*m)
+			if len(args) == 1 {
+				path = args[0]
+			}
+			return m.writeReport(path)
+		},
+	},
+	"timecol": {
+		usage: "timecol <column|auto>",
+		args:  1,
+		run: func(m *model, args []string) tea.Cmd {
+			if err := m.setTimeColumn(args[0]); err != nil {
+				return m.startNotice(err.Error(), "warn", noticeDuration)
+			}
+			m.refreshView("time-column", true)
+			if m.data.timeColumnIndex < 0 {
+				return m.startNotice("No column parses as timestamps", "warn", noticeDuration)
+			}
+			name := m.data.header[m.data.timeColumnIndex].Name
+			if !m.data.hasTimeBounds {
+				return m.startNotice(fmt.Sprintf("Time column is %s, but none of its values parse as timestamps", name), "warn", noticeDuration)
+			}
+			return m.startNotice(fmt.Sprintf("Time column is %s", name), "success", noticeDuration)
+		},
+	},
+}
+
+// namedCommandNames lists the commands for the prompt hint.
+func namedCommandNames() []string {
+	names := make([]string, 0, len(namedCommands))
+	for name := range namedCommands {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// runNamedCommand parses and runs a ":" command line that is not a line number.
+func (m *model) runNamedCommand(line string) tea.Cmd {
+	fields := splitQuoted(strings.TrimSpace(line))
+	if len(fields) == 0 {
+		return nil
+	}
+	for i := range fields {
+		fields[i] = strings.Trim(fields[i], `"`)
+	}
+	c, ok := namedCommands[strings.ToLower(fields[0])]
+	if !ok {
+		return m.startNotice(fmt.Sprintf("Unknown command %q (try %s)", fields[0], strings.Join(namedCommandNames(), ", ")), "warn", noticeDuration)
+	}
+	if c.args >= 0 && len(fields)-1 != c.args {
+		return m.startNotice("Usage: "+c.usage, "warn", noticeDuration)
+	}
+	return c.run(m, fields[1:])
+}
@@ -1,7 +1,9 @@
 package main
 
 import (
+	"fmt"
 	"strconv"
+	"strings"
 	"unicode"
 
 	tea "github.com/charmbracelet/bubbletea"
@@ -10,10 +12,10 @@ import (
 func (m *model) runCommand() tea.Cmd {
 	switch m.ui.command.cmd {
 	case CmdJump:
-		if n, err := strconv.Atoi(m.ui.command.buf); err == nil {
+		if n, err := strconv.Atoi(strings.TrimSpace(m.ui.command.buf)); err == nil {
 			return m.jumpToLine(n)
 		}
-		return m.startNotice("Invalid line number", "warn", noticeDuration)
+		return m.runNamedCommand(m.ui.command.buf)
 
 	case CmdSearch:
 		m.setSearchQuery(m.ui.command.buf)
@@ -23,7 +25,9 @@ func (m *model) runCommand() tea.Cmd {
 		return m.startNotice("No matches", "warn", noticeDuration)
 
 	case CmdFilter:
-		m.setFilterPattern(m.ui.command.buf)
+		if err := m.setFilterPattern(m.ui.command.buf); err != nil {
+			return m.startNotice(fmt.Sprintf("Filter error: %v", err), "warn", noticeDuration)
+		}
 		return nil
 
 	case CmdComment:
@@ -0,0 +1,331 @@
+package main
+
+import (
+	"cmp"
+	"fmt"
+	"net"
+	"net/netip"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// ColumnType is the kind of value a column holds. It decides how values are
+// compared when sorting and filtering and how they are aligned on screen.
+type ColumnType int
+
+const (
+	TypeUnset ColumnType = iota // not inferred yet, no data seen
+	TypeText
+	TypeTimestamp
+	TypeInt
+	TypeFloat
+	TypeIPv4
+	TypeIPv6 // also used for columns mixing IPv4 and IPv6 addresses
+	TypeMAC
+)
+
+// typeSampleSize is how many non-empty values are looked at to infer a type.
+const typeSampleSize = 200
+
+var columnTypeNames = map[ColumnType]string{
+	TypeUnset:     "auto",
+	TypeText:      "text",
+	TypeTimestamp: "timestamp",
+	TypeInt:       "int",
+	TypeFloat:     "float",
+	TypeIPv4:      "ipv4",
+	TypeIPv6:      "ipv6",
+	TypeMAC:       "mac",
+}
+
+func (t ColumnType) String() string {
+	if name, ok := columnTypeNames[t]; ok {
+		return name
+	}
+	return fmt.Sprintf("ColumnType(%d)", int(t))
+}
+
+// parseColumnType accepts the names shown by String, plus a few aliases.
+func parseColumnType(s string) (ColumnType, error) {
+	switch strings.ToLower(strings.TrimSpace(s)) {
+	case "auto", "":
+		return TypeUnset, nil
+	case "text", "string":
+		return TypeText, nil
+	case "timestamp", "time":
+		return TypeTimestamp, nil
+	case "int", "integer":
+		return TypeInt, nil
+	case "float", "number":
+		return TypeFloat, nil
+	case "ipv4", "ip":
+		return TypeIPv4, nil
+	case "ipv6":
+		return TypeIPv6, nil
+	case "mac":
+		return TypeMAC, nil
+	}
+	return TypeUnset, fmt.Errorf("unknown column type %q (want text, timestamp, int, float, ipv4, ipv6, mac or auto)", s)
+}
+
+// MarshalText keeps the type readable in snapshots.
+func (t ColumnType) MarshalText() ([]byte, error) {
+	return []byte(t.String()), nil
+}
+
+func (t *ColumnType) UnmarshalText(b []byte) error {
+	parsed, err := parseColumnType(string(b))
+	if err != nil {
+		return err
+	}
+	*t = parsed
+	return nil
+}
+
+// numeric reports whether values of this type are right-aligned.
+func (t ColumnType) numeric() bool {
+	return t == TypeInt || t == TypeFloat
+}
+
+// inferColumnTypes types every column not set by the user from rows, either
+// every row or a batch just read. A column still unset takes the narrowest
+// type its first values fit, and a typed one is widened until all of rows
+// fit. The active filter is recompiled when any type changed.
+func (m *model) inferColumnTypes(rows []renderedRow) {
+	changed := false
+	for i := range m.data.header {
+		col := &m.data.header[i]
+		if col.TypeSet {
+			continue
+		}
+		if t := m.inferredType(i, col.Type, rows); t != col.Type {
+			col.Type = t
+			changed = true
+		}
+	}
+	if changed {
+		m.recompileFilter()
+	}
+}
+
+// reinferColumn types column i afresh from every row, unless the user set
+// its type, and reports whether the type changed.
+func (m *model) reinferColumn(i int) bool {
+	if i < 0 || i >= len(m.data.header) || m.data.header[i].TypeSet {
+		return false
+	}
+	col := &m.data.header[i]
+	before := col.Type
+	col.Type = m.inferredType(i, TypeUnset, m.data.rows)
+	return col.Type != before
+}
+
+// inferredType is t, column i's type so far, once the column's values in rows
+// are taken into account. The time column is always a timestamp; values
+// that do not parse just sort after those that do.
+func (m *model) inferredType(i int, t ColumnType, rows []renderedRow) ColumnType {
+	var samples []string
+	for _, row := range rows {
+		if i >= len(row.cols) {
+			continue
+		}
+		v := strings.TrimSpace(row.cols[i])
+		switch {
+		case v == "":
+		case i == m.data.timeColumnIndex:
+			return TypeTimestamp
+		case t == TypeUnset:
+			samples = append(samples, v)
+			if len(samples) == typeSampleSize {
+				t = m.inferType(samples)
+			}
+		default:
+			t = m.widenType(t, v)
+		}
+	}
+	if t == TypeUnset && len(samples) > 0 {
+		t = m.inferType(samples)
+	}
+	return t
+}
+
+// inferOrder lists the types inference tries, narrowest first.
+var inferOrder = []ColumnType{TypeInt, TypeFloat, TypeIPv4, TypeIPv6, TypeMAC, TypeTimestamp}
+
+// inferType returns the narrowest type every sample fits.
+func (m *model) inferType(samples []string) ColumnType {
+	for _, t := range inferOrder {
+		fits := true
+		for _, s := range samples {
+			if !m.fitsType(t, s) {
+				fits = false
+				break
+			}
+		}
+		if fits {
+			return t
+		}
+	}
+	return TypeText
+}
+
+// widenType widens t until v fits: int to float, ipv4 to ipv6, and anything
+// else to text.
+func (m *model) widenType(t ColumnType, v string) ColumnType {
+	for !m.fitsType(t, v) {
+		switch t {
+		case TypeInt:
+			t = TypeFloat
+		case TypeIPv4:
+			t = TypeIPv6
+		default:
+			t = TypeText
+		}
+	}
+	return t
+}
+
+// fitsType reports whether v, already trimmed, is a value of type t.
+func (m *model) fitsType(t ColumnType, v string) bool {
+	switch t {
+	case TypeInt:
+		return isInt(v)
+	case TypeFloat:
+		return isFloat(v)
+	case TypeIPv4:
+		return isIPv4(v)
+	case TypeIPv6:
+		return isIP(v)
+	case TypeMAC:
+		return isMAC(v)
+	case TypeTimestamp:
+		_, ok := m.parseRowTime(v)
+		return ok
+	}
+	return true
+}
+
+func isInt(s string) bool {
+	_, err := strconv.ParseInt(s, 10, 64)
+	return err == nil
+}
+
+// isFloat rejects the words ParseFloat accepts ("inf", "nan") so that a
+// column of such words stays text.
+func isFloat(s string) bool {
+	if strings.IndexFunc(s, unicode.IsDigit) < 0 {
+		return false
+	}
+	_, err := strconv.ParseFloat(s, 64)
+	return err == nil
+}
+
+func isIPv4(s string) bool {
+	addr, err := netip.ParseAddr(s)
+	return err == nil && addr.Is4()
+}
+
+func isIP(s string) bool {
+	_, err := netip.ParseAddr(s)
+	return err == nil
+}
+
+// isMAC takes the usual colon, dash and dotted notations; longer hardware
+// addresses (EUI-64, InfiniBand) are left as text.
+func isMAC(s string) bool {
+	hw, err := net.ParseMAC(s)
+	return err == nil && len(hw) == 6
+}
+
+// compareTyped orders two cell values by the column type. Values that do not
+// parse as the type sort after those that do, and among themselves as text.
+func (m *model) compareTyped(t ColumnType, a, b string) int {
+	ka, oka := m.typedKey(t, a)
+	kb, okb := m.typedKey(t, b)
+	switch {
+	case oka && okb:
+		return compareKeys(ka, kb)
+	case oka:
+		return -1
+	case okb:
+		return 1
+	}
+	return strings.Compare(strings.ToLower(a), strings.ToLower(b))
+}
+
+// typedKey parses a cell into something comparable for its type.
+func (m *model) typedKey(t ColumnType, v string) (any, bool) {
+	v = strings.TrimSpace(v)
+	if v == "" {
+		return nil, false
+	}
+	switch t {
+	case TypeInt:
+		n, err := strconv.ParseInt(v, 10, 64)
+		if err != nil {
+			// a stray fraction should still sort numerically
+			f, ferr := strconv.ParseFloat(v, 64)
+			return f, ferr == nil
+		}
+		return float64(n), true
+	case TypeFloat:
+		f, err := strconv.ParseFloat(v, 64)
+		return f, err == nil
+	case TypeIPv4, TypeIPv6:
+		addr, err := netip.ParseAddr(v)
+		return addr, err == nil
+	case TypeMAC:
+		hw, err := net.ParseMAC(v)
+		return hw.String(), err == nil
+	case TypeTimestamp:
+		ts, ok := m.parseRowTime(v)
+		return ts, ok
+	}
+	return strings.ToLower(v), true
+}
+
+// compareKeys orders two typedKey values. Keys of different types, e.g. a
+// filter value typed before its column was retyped, are compared as text.
+func compareKeys(a, b any) int {
+	switch ka := a.(type) {
+	case float64:
+		if kb, ok := b.(float64); ok {
+			return cmp.Compare(ka, kb)
+		}
+	case netip.Addr:
+		if kb, ok := b.(netip.Addr); ok {
+			return ka.Compare(kb)
+		}
+	case time.Time:
+		if kb, ok := b.(time.Time); ok {
+			return ka.Compare(kb)
+		}
+	case string:
+		if kb, ok := b.(string); ok {
+			return strings.Compare(ka, kb)
+		}
+	}
+	return strings.Compare(fmt.Sprint(a), fmt.Sprint(b))
+}
+
+// setColumnType overrides a column's type; "auto" re-infers it from the data.
+func (m *model) setColumnType(name, typeName string) error {
+	idx := findColumnIndex(m.data.header, name)
+	if idx < 0 {
+		return fmt.Errorf("no column %q", name)
+	}
+	t, err := parseColumnType(typeName)
+	if err != nil {
+		return err
+	}
+	col := &m.data.header[idx]
+	col.Type, col.TypeSet = t, t != TypeUnset
+	if t == TypeUnset {
+		m.reinferColumn(idx)
+	}
+	m.recompileFilter()
+	m.applyFilter()
+	return nil
+}
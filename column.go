@@ -14,12 +14,26 @@ type ColumnMeta struct {
 	Name     string
 	Index    int
 	Role     ColumnRole
+	Type     ColumnType
+	TypeSet  bool // Type was set with :type and is not inferred
 	Visible  bool
 	MinWidth int
 	Weight   float64
 	Width    int
 }
 
+// findColumnIndex finds the named column case-insensitively, or -1. Spaces in
+// the column name may be left out so names can be typed as a single word.
+func findColumnIndex(cols []ColumnMeta, name string) int {
+	want := strings.ToLower(strings.ReplaceAll(name, " ", ""))
+	for i := range cols {
+		if strings.ToLower(strings.ReplaceAll(cols[i].Name, " ", "")) == want {
+			return i
+		}
+	}
+	return -1
+}
+
 func detectRole(name string) ColumnRole {
 	n := strings.ToLower(strings.TrimSpace(name))
 	switch n {
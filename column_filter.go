@@ -0,0 +1,218 @@
+package main
+
+import (
+	"fmt"
+	"net/netip"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// filterOps in the order they are tried, so two-character operators win.
+var filterOps = []string{"!=", ">=", "<=", "!~", "=", ">", "<", "~"}
+
+// filterTimeLayouts are accepted for timestamp values in a column predicate,
+// alongside the column's own format.
+var filterTimeLayouts = []string{timeInputLayout, "2006-01-02 15:04", "2006-01-02", time.RFC3339Nano}
+
+// columnPredicate is one "@Column<op>value" term of a filter, e.g. @Status>=500,
+// @IP=10.0.0.0/8 or @Host~^fw. Comparisons follow the column's type.
+type columnPredicate struct {
+	col    int
+	op     string
+	value  string
+	key    any  // value parsed for the column type
+	keyOK  bool // value parsed; ordering operators need it
+	prefix netip.Prefix
+	re     *regexp.Regexp
+}
+
+// splitFilterPattern pulls the @column predicates out of a filter; what is
+// left is the free-text regex, exactly as typed. Only "@Name<op>value" terms
+// naming a known column are predicates, so "@example.com" stays a regex.
+func (m *model) splitFilterPattern(pattern string) (string, []columnPredicate, error) {
+	var rest strings.Builder
+	var preds []columnPredicate
+	from := 0
+	for _, span := range quotedSpans(pattern) {
+		tok := pattern[span[0]:span[1]]
+		if !m.isPredicate(tok) {
+			continue
+		}
+		p, err := m.parsePredicate(tok[1:])
+		if err != nil {
+			return "", nil, err
+		}
+		preds = append(preds, p)
+
+		// drop the term and the blanks after it, or before it at the end
+		end := span[1]
+		for end < len(pattern) && (pattern[end] == ' ' || pattern[end] == '\t') {
+			end++
+		}
+		start := span[0]
+		if end == len(pattern) {
+			for start > from && (pattern[start-1] == ' ' || pattern[start-1] == '\t') {
+				start--
+			}
+		}
+		rest.WriteString(pattern[from:start])
+		from = end
+	}
+	if len(preds) == 0 {
+		return pattern, nil, nil
+	}
+	rest.WriteString(pattern[from:])
+	return rest.String(), preds, nil
+}
+
+// isPredicate reports whether tok is an "@Name<op>..." term for a column.
+func (m *model) isPredicate(tok string) bool {
+	if !strings.HasPrefix(tok, "@") {
+		return false
+	}
+	opAt := strings.IndexAny(tok[1:], "!=<>~")
+	return opAt > 0 && findColumnIndex(m.data.header, tok[1:opAt+1]) >= 0
+}
+
+func (m *model) parsePredicate(term string) (columnPredicate, error) {
+	opAt := strings.IndexAny(term, "!=<>~")
+	if opAt <= 0 {
+		return columnPredicate{}, fmt.Errorf("@%s: want @Column<op>value with op one of = != > >= < <= ~ !~", term)
+	}
+	name := term[:opAt]
+	var p columnPredicate
+	for _, op := range filterOps {
+		if strings.HasPrefix(term[opAt:], op) {
+			p.op = op
+			break
+		}
+	}
+	if p.op == "" {
+		return columnPredicate{}, fmt.Errorf("@%s: unknown operator", term)
+	}
+	p.col = findColumnIndex(m.data.header, name)
+	if p.col < 0 {
+		return columnPredicate{}, fmt.Errorf("@%s: no column %q", term, name)
+	}
+	p.value = strings.Trim(term[opAt+len(p.op):], `"`)
+
+	t := m.data.header[p.col].Type
+	switch {
+	case p.op == "~" || p.op == "!~":
+		re, err := regexp.Compile("(?i)" + p.value)
+		if err != nil {
+			return columnPredicate{}, fmt.Errorf("@%s: %w", term, err)
+		}
+		p.re = re
+		return p, nil
+	case (t == TypeIPv4 || t == TypeIPv6) && strings.Contains(p.value, "/"):
+		prefix, err := netip.ParsePrefix(p.value)
+		if err != nil {
+			return columnPredicate{}, fmt.Errorf("@%s: %w", term, err)
+		}
+		p.prefix = prefix.Masked()
+		return p, nil
+	case t == TypeTimestamp:
+		p.key, p.keyOK = m.parseFilterTime(p.value)
+	default:
+		p.key, p.keyOK = m.typedKey(t, p.value)
+	}
+	if !p.keyOK && p.op != "=" && p.op != "!=" {
+		return columnPredicate{}, fmt.Errorf("@%s: %q is not a valid %s", term, p.value, t)
+	}
+	return p, nil
+}
+
+// parseFilterTime accepts the column's own format or a typed-in date, read
+// in the display zone.
+func (m *model) parseFilterTime(v string) (time.Time, bool) {
+	if ts, ok := m.parseRowTime(v); ok {
+		return ts, true
+	}
+	loc := m.displayLocation()
+	for _, layout := range filterTimeLayouts {
+		if ts, err := time.ParseInLocation(layout, v, loc); err == nil {
+			return ts, true
+		}
+	}
+	return time.Time{}, false
+}
+
+// matches evaluates the predicate against one row.
+func (p columnPredicate) matches(m *model, row renderedRow) bool {
+	v := ""
+	if p.col < len(row.cols) {
+		v = strings.TrimSpace(row.cols[p.col])
+	}
+	negate := strings.HasPrefix(p.op, "!")
+
+	if p.re != nil {
+		return p.re.MatchString(v) != negate
+	}
+	if p.prefix.IsValid() {
+		addr, err := netip.ParseAddr(v)
+		return (err == nil && p.prefix.Contains(addr.Unmap())) != negate
+	}
+
+	t := m.data.header[p.col].Type
+	key, ok := m.typedKey(t, v)
+	if !ok || !p.keyOK {
+		if p.op == "=" || p.op == "!=" {
+			return strings.EqualFold(v, p.value) != negate
+		}
+		return false
+	}
+	c := compareKeys(key, p.key)
+	switch p.op {
+	case "=":
+		return c == 0
+	case "!=":
+		return c != 0
+	case ">":
+		return c > 0
+	case ">=":
+		return c >= 0
+	case "<":
+		return c < 0
+	case "<=":
+		return c <= 0
+	}
+	return false
+}
+
+// splitQuoted splits on whitespace, keeping double-quoted runs together.
+func splitQuoted(s string) []string {
+	spans := quotedSpans(s)
+	tokens := make([]string, len(spans))
+	for i, span := range spans {
+		tokens[i] = s[span[0]:span[1]]
+	}
+	return tokens
+}
+
+// quotedSpans returns the byte ranges of the tokens splitQuoted returns.
+func quotedSpans(s string) [][2]int {
+	var spans [][2]int
+	start := -1
+	inQuotes := false
+	for i, r := range s {
+		switch {
+		case r == '"':
+			inQuotes = !inQuotes
+		case (r == ' ' || r == '\t') && !inQuotes:
+			if start >= 0 {
+				spans = append(spans, [2]int{start, i})
+				start = -1
+			}
+			continue
+		}
+		if start < 0 {
+			start = i
+		}
+	}
+	if start >= 0 {
+		spans = append(spans, [2]int{start, len(s)})
+	}
+	return spans
+}
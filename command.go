@@ -1,6 +1,8 @@
 package main
 
 import (
+	"strings"
+
 	tea "github.com/charmbracelet/bubbletea"
 )
 
@@ -57,7 +59,7 @@ func (m *model) commandPrompt(cmd Command) string {
 	case CmdFilter:
 		return "regex filter: "
 	case CmdJump:
-		return "jump to line: "
+		return "line or command: "
 	case CmdComment:
 		return "comment: "
 	case CmdMark:
@@ -70,7 +72,9 @@ func (m *model) commandPrompt(cmd Command) string {
 func (m *model) commandHintsLine(cmd Command) string {
 	switch cmd {
 	case CmdFilter:
-		return "enter: apply esc: cancel (regex is defaulted to case insensitive)"
+		return "enter: apply esc: cancel (regex is defaulted to case insensitive; @Col>value filters on a column)"
+	case CmdJump:
+		return "enter: apply esc: cancel (a number jumps to that line; commands: " + strings.Join(namedCommandNames(), ", ") + ")"
 	case CmdMark:
 		return "r/g/a: mark   c: clear   esc: cancel"
 	default:
@@ -92,7 +96,7 @@ func (m *model) enterCommand(cmd Command, seed string, showHint bool, refresh bo
 	} else {
 		switch cmd {
 		case CmdFilter:
-			if m.data.filterRegex != nil {
+			if m.data.filterPattern != "" {
 				m.ui.command.buf = m.data.filterPattern
 			} else {
 				m.ui.command.buf = ""
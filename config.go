@@ -0,0 +1,52 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+)
+
+// configFileName lives under the user config dir, e.g. ~/.config/siftly-hostlog/config.json.
+const configFileName = "config.json"
+
+// appConfig is the optional user configuration file. Every field may be left out.
+type appConfig struct {
+	Profiles     []parserProfile `json:"profiles,omitempty"`
+	TimeLayouts  []string        `json:"timeLayouts,omitempty"`  // tried in order; Go layouts or hostlog, iso8601, syslog, epoch-ms, epoch
+	IDColumns    []string        `json:"idColumns,omitempty"`    // columns that identify a row for marks and comments, default all
+	SnapshotRows string          `json:"snapshotRows,omitempty"` // inline (default) or source: refer to the loaded file instead of storing its rows
+	Autosave     string          `json:"autosave,omitempty"`     // interval such as "5m", empty or "0" for off
+	Backups      *int            `json:"backups,omitempty"`      // earlier copies kept when saving, default 3
+}
+
+// configPath returns where the user config file is expected to be.
+func configPath() (string, error) {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "siftly-hostlog", configFileName), nil
+}
+
+// loadConfig reads the user config file; a missing file is not an error.
+func loadConfig() (appConfig, error) {
+	var cfg appConfig
+	path, err := configPath()
+	if err != nil {
+		return cfg, nil
+	}
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return cfg, nil
+	}
+	if err != nil {
+		return cfg, err
+	}
+	if err := json.Unmarshal(data, &cfg); err != nil {
+		return cfg, fmt.Errorf("%s: %w", path, err)
+	}
+	return cfg, nil
+}
@@ -19,12 +19,27 @@ type dataState struct {
 	showOnlyMarked  bool
 	filterRegex     *regexp.Regexp
 	filterPattern   string
-	filteredIndices []int // to store the list of indicides that match the current regex
+	filterPreds     []columnPredicate // @Column<op>value terms of the filter
+	filteredIndices []int             // to store the list of indicides that match the current regex
 	timeWindow      TimeWindow
 	timeMin         time.Time
 	timeMax         time.Time
 	hasTimeBounds   bool
 	timeColumnIndex int
+	timeColumn      string         // name of the time column, "" to detect it
+	timeLayout      string         // per-file timestamp format (profile or --time-layout), "" to try timeLayouts
+	timeLayoutHit   string         // the timeLayouts entry that last matched, tried first
+	timeRef         time.Time      // year source for timeLayouts that have none
+	sourceZone      *time.Location // zone for timestamps that carry none (--tz), nil for UTC
+	sourceZoneName  string         // as given, kept for snapshots
+	profile         string         // parser profile the rows came through, if any
 	rowTimes        []time.Time
 	rowHasTimes     []bool
+	rowSeqs         []int64 // ":sequence" suffix of the time cell, -1 if none
+	sortColumn      string  // column filteredIndices is ordered by, "" for source order
+	sortDesc        bool
+	sortedAs        sortSpec    // the order filteredIndices was last sorted in
+	idColumns       []string    // columns rows are keyed on for marks and comments, nil for all
+	unmatchedNotes  int         // annotations in a loaded snapshot that match no row
+	source          *sourceFile // file the rows were read from, nil for stdin, merges and --follow
 }
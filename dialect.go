@@ -0,0 +1,222 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/andareed/siftly-hostlog/logging"
+	"golang.org/x/text/encoding"
+	"golang.org/x/text/encoding/charmap"
+	"golang.org/x/text/encoding/unicode"
+	"golang.org/x/text/transform"
+)
+
+const (
+	encodingUTF8    = "utf-8"
+	encodingUTF16LE = "utf-16le"
+	encodingUTF16BE = "utf-16be"
+	encodingLatin1  = "iso-8859-1"
+	encodingCP1252  = "windows-1252"
+)
+
+var (
+	utf16LEBOM = []byte{0xff, 0xfe}
+	utf16BEBOM = []byte{0xfe, 0xff}
+)
+
+// delimiterCandidates are tried in order; the first wins a tie.
+var delimiterCandidates = []rune{',', '\t', ';', '|'}
+
+// normalizeEncodingName maps the spellings accepted by --encoding onto one name.
+func normalizeEncodingName(name string) (string, error) {
+	switch strings.ToLower(strings.TrimSpace(name)) {
+	case "", "auto":
+		return "", nil
+	case "utf-8", "utf8":
+		return encodingUTF8, nil
+	case "utf-16le", "utf16le", "utf-16", "utf16":
+		return encodingUTF16LE, nil
+	case "utf-16be", "utf16be":
+		return encodingUTF16BE, nil
+	case "iso-8859-1", "latin1", "latin-1":
+		return encodingLatin1, nil
+	case "windows-1252", "cp1252":
+		return encodingCP1252, nil
+	default:
+		return "", fmt.Errorf("unsupported encoding %q (want utf-8, utf-16le, utf-16be, latin1 or windows-1252)", name)
+	}
+}
+
+// parseDelimiter turns a --delimiter value into a rune; "tab" and `\t` are accepted for TSV.
+func parseDelimiter(s string) (rune, error) {
+	switch strings.ToLower(s) {
+	case "":
+		return 0, nil
+	case "tab", `\t`:
+		return '\t', nil
+	case "comma":
+		return ',', nil
+	case "semicolon":
+		return ';', nil
+	case "pipe":
+		return '|', nil
+	}
+	r, size := utf8.DecodeRuneInString(s)
+	if size != len(s) || r == '"' || r == '\r' || r == '\n' || r == utf8.RuneError {
+		return 0, fmt.Errorf("invalid delimiter %q (want a single character or \"tab\")", s)
+	}
+	return r, nil
+}
+
+// detectEncoding guesses the text encoding from the head of the input: a BOM
+// decides outright, otherwise BOM-less UTF-16 gives itself away through its
+// zero bytes, and anything that is not valid UTF-8 is taken to be Windows-1252.
+func detectEncoding(head []byte) string {
+	switch {
+	case bytes.HasPrefix(head, utf8BOM):
+		return encodingUTF8
+	case bytes.HasPrefix(head, utf16LEBOM):
+		return encodingUTF16LE
+	case bytes.HasPrefix(head, utf16BEBOM):
+		return encodingUTF16BE
+	}
+
+	sample := head
+	if len(sample) > 512 {
+		sample = sample[:512]
+	}
+	evenZeros, oddZeros := 0, 0
+	for i, b := range sample {
+		if b != 0 {
+			continue
+		}
+		if i%2 == 0 {
+			evenZeros++
+		} else {
+			oddZeros++
+		}
+	}
+	if half := len(sample) / 2; half > 0 {
+		if oddZeros*10 > half*3 && evenZeros*10 < half {
+			return encodingUTF16LE
+		}
+		if evenZeros*10 > half*3 && oddZeros*10 < half {
+			return encodingUTF16BE
+		}
+	}
+
+	if utf8.Valid(trimPartialRune(head)) {
+		return encodingUTF8
+	}
+	return encodingCP1252
+}
+
+// trimPartialRune drops a multi-byte sequence cut off by the sniff window.
+func trimPartialRune(b []byte) []byte {
+	for i := 1; i <= utf8.UTFMax && i <= len(b); i++ {
+		if utf8.RuneStart(b[len(b)-i]) {
+			if !utf8.FullRune(b[len(b)-i:]) {
+				return b[:len(b)-i]
+			}
+			break
+		}
+	}
+	return b
+}
+
+// decode converts the input to UTF-8 (dropping any BOM) so everything
+// downstream only ever sees clean UTF-8. An empty name means auto-detect.
+func (in *inputFile) decode(name string) error {
+	head, _ := in.Peek(sniffSize)
+	if name == "" {
+		name = detectEncoding(head)
+	}
+
+	var dec *encoding.Decoder
+	switch name {
+	case encodingUTF8:
+		if bytes.HasPrefix(head, utf8BOM) {
+			in.Discard(len(utf8BOM))
+		}
+		in.encoding = encodingUTF8
+		return nil
+	case encodingUTF16LE:
+		dec = unicode.UTF16(unicode.LittleEndian, unicode.UseBOM).NewDecoder()
+	case encodingUTF16BE:
+		dec = unicode.UTF16(unicode.BigEndian, unicode.UseBOM).NewDecoder()
+	case encodingLatin1:
+		dec = charmap.ISO8859_1.NewDecoder()
+	case encodingCP1252:
+		dec = charmap.Windows1252.NewDecoder()
+	default:
+		return fmt.Errorf("unsupported encoding %q", name)
+	}
+	logging.Infof("decode: reading input as %s", name)
+	in.encoding = name
+	in.Reader = bufio.NewReader(transform.NewReader(in.Reader, dec))
+	return nil
+}
+
+// detectDelimiter picks the candidate that splits the first lines most
+// consistently, ignoring anything inside quotes.
+func detectDelimiter(head []byte) rune {
+	lines := strings.Split(string(trimPartialRune(head)), "\n")
+	if len(lines) > 1 {
+		lines = lines[:len(lines)-1] // the last line may be cut short by the sniff window
+	}
+	if len(lines) > 10 {
+		lines = lines[:10]
+	}
+
+	best, bestScore := ',', 0
+	for _, cand := range delimiterCandidates {
+		score := 0
+		first := -1
+		for _, line := range lines {
+			n := countOutsideQuotes(line, cand)
+			if first < 0 {
+				first = n
+			}
+			if n == 0 || n != first {
+				continue
+			}
+			score += n
+		}
+		if score > bestScore {
+			best, bestScore = cand, score
+		}
+	}
+	return best
+}
+
+func countOutsideQuotes(line string, delim rune) int {
+	inQuotes := false
+	n := 0
+	for _, r := range line {
+		switch {
+		case r == '"':
+			inQuotes = !inQuotes
+		case r == delim && !inQuotes:
+			n++
+		}
+	}
+	return n
+}
+
+// sniffDelimiter returns the override if set, otherwise the detected delimiter.
+func (in *inputFile) sniffDelimiter(override rune) rune {
+	if override != 0 {
+		return override
+	}
+	head, _ := in.Peek(sniffSize)
+	return detectDelimiter(head)
+}
+
+// normalizeHeaderName strips the BOM and surrounding whitespace that some
+// exports leave on column names.
+func normalizeHeaderName(name string) string {
+	return strings.TrimSpace(strings.TrimPrefix(name, "\ufeff"))
+}
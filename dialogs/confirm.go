@@ -0,0 +1,110 @@
+package dialogs
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/andareed/siftly-hostlog/logging"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// --- Messages ---------------------------------------------------------------
+
+type (
+	// ConfirmChoiceMsg reports the key picked in a Confirm dialog; Key is
+	// empty when it was dismissed with esc.
+	ConfirmChoiceMsg struct {
+		ID  string
+		Key string
+	}
+)
+
+// ConfirmChoice is one answer a Confirm dialog offers, picked by its key.
+type ConfirmChoice struct {
+	Key   string
+	Label string
+}
+
+// Confirm asks a question and waits for one of a few single-key answers.
+type Confirm struct {
+	visible bool
+	id      string
+	message string
+	choices []ConfirmChoice
+}
+
+func (d Confirm) Init() tea.Cmd { return nil }
+
+// NewConfirmDialog creates a dialog whose answer comes back as a
+// ConfirmChoiceMsg carrying id.
+func NewConfirmDialog(id, message string, choices []ConfirmChoice) *Confirm {
+	return &Confirm{
+		visible: true,
+		id:      id,
+		message: message,
+		choices: choices,
+	}
+}
+
+func (d *Confirm) Update(msg tea.Msg) (Dialog, tea.Cmd) {
+	logging.Debug("ConfirmDialog:Update:: Called")
+	if !d.visible {
+		return d, nil
+	}
+	km, ok := msg.(tea.KeyMsg)
+	if !ok {
+		return d, nil
+	}
+	pressed := km.String()
+	if pressed == "esc" {
+		return d, d.answer("")
+	}
+	for _, c := range d.choices {
+		if strings.EqualFold(pressed, c.Key) {
+			return d, d.answer(c.Key)
+		}
+	}
+	return d, nil
+}
+
+func (d *Confirm) answer(key string) tea.Cmd {
+	id := d.id
+	return func() tea.Msg { return ConfirmChoiceMsg{ID: id, Key: key} }
+}
+
+func (d Confirm) View() string {
+	if !d.visible {
+		return ""
+	}
+	box := lipgloss.NewStyle().
+		Border(lipgloss.RoundedBorder()).
+		BorderForeground(lipgloss.Color("252")). // keep your light border
+		BorderBackground(lipgloss.Color("236")). // match the overlay
+		Padding(1, 2).
+		Width(60)
+
+	hints := make([]string, 0, len(d.choices)+1)
+	for _, c := range d.choices {
+		hints = append(hints, fmt.Sprintf("%s %s", c.Key, c.Label))
+	}
+	hints = append(hints, "esc to cancel")
+	help := lipgloss.NewStyle().
+		Faint(true).
+		Render(strings.Join(hints, " • "))
+
+	content := fmt.Sprintf("%s\n\n%s", d.message, help)
+	return box.Render(content)
+}
+
+func (d *Confirm) Show() {
+	d.visible = true
+}
+
+func (d *Confirm) Hide() {
+	d.visible = false
+}
+
+func (d *Confirm) Focus() tea.Cmd { return nil }
+func (d *Confirm) Blur()          {}
+func (d Confirm) IsVisible() bool { return d.visible }
@@ -0,0 +1,101 @@
+package dialogs
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/andareed/siftly-hostlog/logging"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// --- Messages ---------------------------------------------------------------
+
+type (
+	DiagnosticsRequestedMsg struct{}
+)
+
+// diagnosticsPageSize is how many issue lines are shown at once.
+const diagnosticsPageSize = 15
+
+// Diagnostics lists the lines the loader had to repair or skip.
+type Diagnostics struct {
+	visible bool
+	summary string
+	lines   []string
+	offset  int
+}
+
+func (d Diagnostics) Init() tea.Cmd { return nil }
+
+// NewDiagnosticsDialog creates a dialog showing a summary line above the issue list.
+func NewDiagnosticsDialog(summary string, lines []string) *Diagnostics {
+	return &Diagnostics{
+		visible: true,
+		summary: summary,
+		lines:   lines,
+	}
+}
+
+func (d *Diagnostics) Update(msg tea.Msg) (Dialog, tea.Cmd) {
+	logging.Debug("DiagnosticsDialog:Update:: Called")
+
+	switch m := msg.(type) {
+	case tea.KeyMsg:
+		switch m.String() {
+		case "enter", "esc":
+			d.visible = false
+		case "j", "down":
+			if d.offset+diagnosticsPageSize < len(d.lines) {
+				d.offset++
+			}
+		case "k", "up":
+			if d.offset > 0 {
+				d.offset--
+			}
+		}
+	}
+	return d, nil
+}
+
+func (d Diagnostics) View() string {
+	if !d.visible {
+		return ""
+	}
+
+	box := lipgloss.NewStyle().
+		Border(lipgloss.RoundedBorder()).
+		BorderForeground(lipgloss.Color("252")). // keep your light border
+		BorderBackground(lipgloss.Color("236")). // match the overlay
+		Padding(1, 2).
+		Width(80)
+
+	end := d.offset + diagnosticsPageSize
+	if end > len(d.lines) {
+		end = len(d.lines)
+	}
+	shown := d.lines[d.offset:end]
+
+	hint := "enter/esc to return"
+	if len(d.lines) > diagnosticsPageSize {
+		hint = fmt.Sprintf("%d-%d of %d • j/k to scroll • %s", d.offset+1, end, len(d.lines), hint)
+	}
+	helpHint := lipgloss.NewStyle().
+		Faint(true).
+		Render(hint)
+
+	content := fmt.Sprintf("%s\n\n%s\n\n%s", d.summary, strings.Join(shown, "\n"), helpHint)
+	return box.Render(content)
+}
+
+func (d *Diagnostics) Show() {
+	d.visible = true
+}
+
+func (d *Diagnostics) Hide() {
+	d.visible = false
+}
+
+func (d *Diagnostics) Focus() tea.Cmd { return nil }
+func (d *Diagnostics) Blur()          {}
+func (d Diagnostics) IsVisible() bool { return d.visible }
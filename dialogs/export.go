@@ -3,6 +3,7 @@ package dialogs
 import (
 	"fmt"
 	"path/filepath"
+	"strings"
 
 	"github.com/andareed/siftly-hostlog/logging"
 	"github.com/charmbracelet/bubbles/textinput"
@@ -14,10 +15,43 @@ import (
 
 type (
 	ExportRequestedMsg struct{}
-	ExportConfirmedMsg struct{ Path string }
-	ExportCanceledMsg  struct{}
-	ExportErrorMsg     struct{ Err error }
-	ExportOKMsg        struct{ Path string }
+	ExportConfirmedMsg struct {
+		Path    string
+		Options ExportOptions
+	}
+	ExportCanceledMsg struct{}
+	ExportErrorMsg    struct{ Err error }
+	ExportOKMsg       struct{ Path string }
+)
+
+// ExportScopes are the row selections the export dialog cycles through:
+// the rows shown, every row, marked rows, or rows of one colour.
+var ExportScopes = []string{"filtered", "all", "marked", "red", "amber", "green"}
+
+// ExportDelimiters are the field separators offered for CSV output.
+var ExportDelimiters = []string{",", ";", "tab", "|"}
+
+// ExportOptions are the choices made in the export dialog. The zero value
+// exports the filtered rows with every column and a header, comma separated.
+type ExportOptions struct {
+	Scope       string // one of ExportScopes, "" for filtered
+	VisibleOnly bool   // leave out hidden columns
+	LineNumber  bool   // add each row's line number in the source
+	ISOTime     bool   // add the parsed timestamp in ISO 8601
+	Delimiter   string // one of ExportDelimiters, "" for a comma
+	NoHeader    bool
+}
+
+// Export form fields below the path input, in tab order.
+const (
+	exportFieldPath = iota
+	exportFieldScope
+	exportFieldColumns
+	exportFieldLine
+	exportFieldISO
+	exportFieldDelimiter
+	exportFieldHeader
+	exportFieldCount
 )
 
 type Export struct {
@@ -25,11 +59,15 @@ type Export struct {
 	visible bool
 	// optional: remember the last directory
 	lastDir string
+	opts    ExportOptions
+	focus   int
 }
 
 func (d Export) Init() tea.Cmd { return d.input.Focus() }
 
-func NewExportDialog(defaultName, lastDir string) *Export {
+// NewExportDialog creates the export form, starting from the options used
+// last time.
+func NewExportDialog(defaultName, lastDir string, opts ExportOptions) *Export {
 	ti := textinput.New()
 	// Prompt and placeholder
 	ti.Placeholder = defaultName
@@ -40,7 +78,13 @@ func NewExportDialog(defaultName, lastDir string) *Export {
 	if defaultName != "" {
 		ti.SetValue(defaultName)
 	}
-	return &Export{input: ti, visible: true, lastDir: lastDir}
+	if opts.Scope == "" {
+		opts.Scope = ExportScopes[0]
+	}
+	if opts.Delimiter == "" {
+		opts.Delimiter = ExportDelimiters[0]
+	}
+	return &Export{input: ti, visible: true, lastDir: lastDir, opts: opts}
 }
 
 func (d *Export) Update(msg tea.Msg) (Dialog, tea.Cmd) {
@@ -68,10 +112,26 @@ func (d *Export) Update(msg tea.Msg) (Dialog, tea.Cmd) {
 			if d.lastDir != "" && !filepath.IsAbs(path) && filepath.Dir(path) == "." {
 				path = filepath.Join(d.lastDir, filepath.Base(path))
 			}
-			return d, func() tea.Msg { return ExportConfirmedMsg{Path: path} }
+			opts := d.opts
+			return d, func() tea.Msg { return ExportConfirmedMsg{Path: path, Options: opts} }
 		case "esc":
 			logging.Debug("ExportDialog:Update::Esc key was prssed, cancel anything to do with this")
 			return d, func() tea.Msg { return ExportCanceledMsg{} }
+		case "tab", "down":
+			d.setFocus((d.focus + 1) % exportFieldCount)
+			return d, nil
+		case "shift+tab", "up":
+			d.setFocus((d.focus + exportFieldCount - 1) % exportFieldCount)
+			return d, nil
+		}
+		if d.focus != exportFieldPath {
+			switch s {
+			case "left", "h":
+				d.change(-1)
+			case "right", "l", " ":
+				d.change(1)
+			}
+			return d, nil
 		}
 	}
 	var cmd tea.Cmd
@@ -79,6 +139,50 @@ func (d *Export) Update(msg tea.Msg) (Dialog, tea.Cmd) {
 	return d, cmd
 }
 
+func (d *Export) setFocus(focus int) {
+	d.focus = focus
+	if focus == exportFieldPath {
+		d.input.Focus()
+	} else {
+		d.input.Blur()
+	}
+}
+
+// change steps the focused option by delta, wrapping around.
+func (d *Export) change(delta int) {
+	switch d.focus {
+	case exportFieldScope:
+		d.opts.Scope = cycle(ExportScopes, d.opts.Scope, delta)
+	case exportFieldColumns:
+		d.opts.VisibleOnly = !d.opts.VisibleOnly
+	case exportFieldLine:
+		d.opts.LineNumber = !d.opts.LineNumber
+	case exportFieldISO:
+		d.opts.ISOTime = !d.opts.ISOTime
+	case exportFieldDelimiter:
+		d.opts.Delimiter = cycle(ExportDelimiters, d.opts.Delimiter, delta)
+	case exportFieldHeader:
+		d.opts.NoHeader = !d.opts.NoHeader
+	}
+}
+
+func cycle(values []string, cur string, delta int) string {
+	i := 0
+	for j, v := range values {
+		if v == cur {
+			i = j
+		}
+	}
+	return values[(i+delta+len(values))%len(values)]
+}
+
+func onOff(b bool) string {
+	if b {
+		return "on"
+	}
+	return "off"
+}
+
 func (d Export) View() string {
 	if !d.visible {
 		return ""
@@ -90,17 +194,43 @@ func (d Export) View() string {
 		Padding(1, 2).
 		Width(60) // no .Background()
 
+	columns := "all"
+	if d.opts.VisibleOnly {
+		columns = "visible"
+	}
+	fields := []struct {
+		label, value string
+	}{
+		{"Scope", d.opts.Scope},
+		{"Columns", columns},
+		{"Line number", onOff(d.opts.LineNumber)},
+		{"ISO time", onOff(d.opts.ISOTime)},
+		{"Delimiter", d.opts.Delimiter},
+		{"Header", onOff(!d.opts.NoHeader)},
+	}
+	focused := lipgloss.NewStyle().Bold(true).Reverse(true)
+	rows := make([]string, len(fields))
+	for i, f := range fields {
+		value := "‹ " + f.value + " ›"
+		marker := "  "
+		if d.focus == i+1 {
+			marker = "▸ "
+			value = focused.Render(value)
+		}
+		rows[i] = fmt.Sprintf("%s%-12s %s", marker, f.label, value)
+	}
+
 	help := lipgloss.NewStyle().
 		Faint(true).
-		Render("enter to export • esc to cancel")
+		Render("name it .xlsx for a spreadsheet (delimiter and header are CSV only)\ntab/↑↓ to move • ←/→ to change • enter to export • esc to cancel")
 
-	content := fmt.Sprintf("%s\n\n%s", d.input.View(), help)
+	content := fmt.Sprintf("%s\n\n%s\n\n%s", d.input.View(), strings.Join(rows, "\n"), help)
 	return box.Render(content)
 }
 
 func (d *Export) Show() {
 	d.visible = true
-	d.input.Focus()
+	d.setFocus(exportFieldPath)
 }
 
 func (d *Export) Hide() {
@@ -0,0 +1,111 @@
+package main
+
+import (
+	"encoding/binary"
+	"hash/fnv"
+	"time"
+
+	"github.com/andareed/siftly-hostlog/dialogs"
+	"github.com/andareed/siftly-hostlog/logging"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// confirmQuit identifies the dialog asking what to do with unsaved changes.
+const confirmQuit = "quit-unsaved"
+
+// sessionFingerprint hashes what a save keeps that the user can change:
+// marks, comments, the filter and the time window. Comparing it with the
+// value at the last save tells whether there is anything unsaved, and an
+// edit that is put back counts as no change.
+func (m *model) sessionFingerprint() uint64 {
+	var sum uint64
+	var buf [8]byte
+	entry := func(kind byte, id uint64, v string) {
+		h := fnv.New64a()
+		h.Write([]byte{kind})
+		binary.LittleEndian.PutUint64(buf[:], id)
+		h.Write(buf[:])
+		h.Write([]byte(v))
+		sum += h.Sum64() // order-independent, map iteration is random
+	}
+	for id, c := range m.data.markedRows {
+		entry('m', id, string(c))
+	}
+	for id, c := range m.data.commentRows {
+		entry('c', id, c)
+	}
+
+	h := fnv.New64a()
+	binary.LittleEndian.PutUint64(buf[:], sum)
+	h.Write(buf[:])
+	h.Write([]byte(m.data.filterPattern))
+	h.Write([]byte{0})
+	w := m.data.timeWindow
+	if w.Enabled {
+		h.Write([]byte(w.Start.Format(time.RFC3339Nano)))
+		h.Write([]byte{0})
+		h.Write([]byte(w.End.Format(time.RFC3339Nano)))
+	}
+	return h.Sum64()
+}
+
+// markSaved records the current state as the one on disk.
+func (m *model) markSaved() {
+	m.savedState = m.sessionFingerprint()
+	m.autosavedState = m.savedState
+	m.isDirty, m.dirtyKnown = false, true
+}
+
+// touchSession notes that marks, comments, the filter or the time window may
+// have changed, so the next dirty check hashes the session again.
+func (m *model) touchSession() {
+	m.dirtyKnown = false
+}
+
+// dirty reports whether marks, comments, the filter or the time window have
+// changed since the session was loaded or last saved. The answer is kept
+// until touchSession, as the footer asks on every render.
+func (m *model) dirty() bool {
+	if !m.dirtyKnown {
+		m.isDirty = m.sessionFingerprint() != m.savedState
+		m.dirtyKnown = true
+	}
+	return m.isDirty
+}
+
+// quit leaves straight away when there is nothing unsaved, and otherwise
+// asks first.
+func (m *model) quit() tea.Cmd {
+	if !m.dirty() {
+		return tea.Quit
+	}
+	m.activeDialog = dialogs.NewConfirmDialog(confirmQuit, "There are unsaved marks, comments or filter changes.\n\nSave to "+defaultSaveName(*m)+" before quitting?", []dialogs.ConfirmChoice{
+		{Key: "s", Label: "save and quit"},
+		{Key: "d", Label: "discard and quit"},
+	})
+	m.activeDialog.Show()
+	return nil
+}
+
+// answerQuit acts on the choice made in the unsaved-changes dialog.
+func (m *model) answerQuit(choice string) tea.Cmd {
+	switch choice {
+	case "s":
+		if m.load.busy() {
+			return m.startNotice("Still loading rows, save once the load completes", "warn", noticeDuration)
+		}
+		path := defaultSaveName(*m)
+		if err := SaveModel(m, path); err != nil {
+			logging.Errorf("answerQuit: %v", err)
+			return m.startNotice("Save failed, not quitting: "+err.Error(), "error", noticeDuration)
+		}
+		m.markSaved()
+		m.clearAutosave()
+		logging.Infof("answerQuit: saved to %s before quitting", path)
+		return tea.Quit
+	case "d":
+		logging.Infof("answerQuit: quitting without saving")
+		return tea.Quit
+	}
+	return nil
+}
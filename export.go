@@ -0,0 +1,150 @@
+package main
+
+import (
+	"bytes"
+	"encoding/csv"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/andareed/siftly-hostlog/dialogs"
+)
+
+// Column names for the extras the export dialog can add in front of the data.
+const (
+	exportLineColumn = "Line"
+	exportISOColumn  = "Time (ISO 8601)"
+)
+
+// exportRows picks the rows an export covers, in the order shown: by the
+// sort column when there is one, otherwise in source order. The filtered
+// scope is the rows on screen, which may be none.
+func (m *model) exportRows(scope string) []int {
+	switch scope {
+	case "all", "marked", string(MarkRed), string(MarkAmber), string(MarkGreen):
+		var indices []int
+		for i, r := range m.data.rows {
+			c, ok := m.data.markedRows[r.id]
+			if scope == "all" || ok && (scope == "marked" || string(c) == scope) {
+				indices = append(indices, i)
+			}
+		}
+		m.sortIndices(indices)
+		return indices
+	}
+	return m.data.filteredIndices
+}
+
+// exportColumns picks the data columns an export writes.
+func (m *model) exportColumns(visibleOnly bool) []int {
+	cols := make([]int, 0, len(m.data.header))
+	for i, col := range m.data.header {
+		if visibleOnly && !col.Visible {
+			continue
+		}
+		cols = append(cols, i)
+	}
+	return cols
+}
+
+// exportISOTime formats the row's parsed timestamp in the display zone.
+func (m *model) exportISOTime(rowIdx int) string {
+	if rowIdx >= len(m.data.rowHasTimes) || !m.data.rowHasTimes[rowIdx] {
+		return ""
+	}
+	return m.data.rowTimes[rowIdx].In(m.displayLocation()).Format(time.RFC3339Nano)
+}
+
+func exportDelimiter(name string) rune {
+	switch name {
+	case "", ",":
+		return ','
+	case "tab":
+		return '\t'
+	}
+	return []rune(name)[0]
+}
+
+// exportHeader names the columns exportRecord fills: the extras, the chosen
+// columns, then Mark and Comment.
+func (m *model) exportHeader(opts dialogs.ExportOptions, cols []int) []string {
+	header := make([]string, 0, len(cols)+4)
+	if opts.LineNumber {
+		header = append(header, exportLineColumn)
+	}
+	if opts.ISOTime {
+		header = append(header, exportISOColumn)
+	}
+	for _, i := range cols {
+		header = append(header, m.data.header[i].Name)
+	}
+	return append(header, exportMarkColumn, exportCommentColumn)
+}
+
+// exportRecord is the row at idx as an export writes it.
+func (m *model) exportRecord(idx int, opts dialogs.ExportOptions, cols []int) []string {
+	r := m.data.rows[idx]
+	out := make([]string, 0, len(cols)+4)
+	if opts.LineNumber {
+		out = append(out, strconv.Itoa(r.originalIndex))
+	}
+	if opts.ISOTime {
+		out = append(out, m.exportISOTime(idx))
+	}
+	for _, i := range cols {
+		v := ""
+		if i < len(r.cols) {
+			v = r.cols[i]
+		}
+		out = append(out, v)
+	}
+
+	// append mark + comment using the row's id
+	return append(out, string(m.data.markedRows[r.id]), m.data.commentRows[r.id])
+}
+
+// ExportModel writes rows to a CSV file as opts asks, with mark color and
+// comment as additional columns, or to a spreadsheet when path ends in .xlsx.
+// CSV goes through writeOutputFile, so the file is replaced atomically and
+// compressed when the name ends in .gz or .zst. Nothing is written when the
+// scope has no rows.
+func ExportModel(m *model, path string, opts dialogs.ExportOptions) error {
+	indices := m.exportRows(opts.Scope)
+	if len(indices) == 0 {
+		scope := opts.Scope
+		if scope == "" {
+			scope = "filtered"
+		}
+		return fmt.Errorf("no %s rows to export", scope)
+	}
+	if isXLSXPath(path) {
+		return exportXLSX(m, path, opts, indices)
+	}
+
+	var buf bytes.Buffer
+	w := csv.NewWriter(&buf)
+	w.Comma = exportDelimiter(opts.Delimiter)
+
+	cols := m.exportColumns(opts.VisibleOnly)
+	if !opts.NoHeader {
+		if err := w.Write(m.exportHeader(opts, cols)); err != nil {
+			return fmt.Errorf("write header: %w", err)
+		}
+	}
+
+	for _, idx := range indices {
+		// sanity check
+		if idx < 0 || idx >= len(m.data.rows) {
+			return fmt.Errorf("filtered index %d out of range", idx)
+		}
+		if err := w.Write(m.exportRecord(idx, opts, cols)); err != nil {
+			return fmt.Errorf("write row %d: %w", idx, err)
+		}
+	}
+
+	w.Flush()
+	if err := w.Error(); err != nil {
+		return fmt.Errorf("flush csv: %w", err)
+	}
+	return writeOutputFile(path, buf.Bytes())
+}
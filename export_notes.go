@@ -0,0 +1,102 @@
+package main
+
+import "strings"
+
+// Column names ExportModel appends after the data columns.
+const (
+	exportMarkColumn    = "Mark"
+	exportCommentColumn = "Comment"
+)
+
+// hasExportNotes reports whether a CSV header ends in the Mark and Comment
+// columns ExportModel writes, i.e. the file is one of our exports.
+func hasExportNotes(header []string) bool {
+	n := len(header)
+	return n > 2 &&
+		strings.EqualFold(normalizeHeaderName(header[n-2]), exportMarkColumn) &&
+		strings.EqualFold(normalizeHeaderName(header[n-1]), exportCommentColumn)
+}
+
+// rowNote is the mark and comment a re-opened export carried for one row.
+type rowNote struct {
+	id      uint64
+	mark    MarkColor
+	comment string
+}
+
+func (n rowNote) empty() bool {
+	return n.mark == MarkNone && n.comment == ""
+}
+
+// exportExtras counts the Line and ISO time columns an export made with
+// those options starts with. They were never part of the rows.
+func exportExtras(header []string) int {
+	n := 0
+	for _, name := range []string{exportLineColumn, exportISOColumn} {
+		if n < len(header)-1 && strings.EqualFold(normalizeHeaderName(header[n]), name) {
+			n++
+		}
+	}
+	return n
+}
+
+// exportNotesSource splits the Mark and Comment columns, and any leading
+// extras, off each record of a re-opened export. The data columns alone make
+// up the row, so its ID matches the one the row had in the session that
+// exported it.
+type exportNotesSource struct {
+	recordSource
+	lead int // extra columns in front of the data
+	last rowNote
+}
+
+// newExportNotesSource reads an export with the given header through src and
+// returns the header of its data columns.
+func newExportNotesSource(src recordSource, header []string) (*exportNotesSource, []string) {
+	data := header[:len(header)-2]
+	lead := exportExtras(data)
+	return &exportNotesSource{recordSource: src, lead: lead}, data[lead:]
+}
+
+func (s *exportNotesSource) Read() ([]string, error) {
+	rec, err := s.recordSource.Read()
+	if err != nil {
+		return rec, err
+	}
+	s.last = rowNote{}
+	if n := len(rec) - 2; n >= 0 {
+		s.last.mark = sanitizeMarkColor(strings.ToLower(strings.TrimSpace(rec[n])))
+		s.last.comment = rec[n+1]
+		rec = rec[:n]
+	}
+	if len(rec) >= s.lead {
+		rec = rec[s.lead:]
+	}
+	return rec, nil
+}
+
+func (s *exportNotesSource) lastRepaired() bool { return sourceRepaired(s.recordSource) }
+
+func (s *exportNotesSource) takeIssues() []loadIssue { return sourceIssues(s.recordSource) }
+
+// sourceNote returns the annotations src split off the record it just returned.
+func sourceNote(src recordSource) rowNote {
+	if ns, ok := src.(*exportNotesSource); ok {
+		return ns.last
+	}
+	return rowNote{}
+}
+
+// applyRowNotes turns the Mark and Comment cells of a re-opened export back
+// into annotations.
+func (m *model) applyRowNotes(notes []rowNote) {
+	m.touchSession()
+	for _, n := range notes {
+		if n.mark != MarkNone {
+			m.data.markedRows[n.id] = n.mark
+		}
+		if n.comment != "" {
+			m.data.commentRows[n.id] = n.comment
+		}
+	}
+}
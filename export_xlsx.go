@@ -0,0 +1,311 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/andareed/siftly-hostlog/dialogs"
+	"github.com/xuri/excelize/v2"
+)
+
+const (
+	xlsxRowsSheet    = "Rows"
+	xlsxSummarySheet = "Summary"
+	xlsxNoteAuthor   = "sfhost"
+)
+
+// xlsxFills are the row fills for each mark, Excel's own light tints so the
+// text stays readable.
+var xlsxFills = map[MarkColor]string{
+	MarkRed:   "FFC7CE",
+	MarkAmber: "FFEB9C",
+	MarkGreen: "C6EFCE",
+}
+
+// hostColumnNames are the headers the summary sheet looks for to count marks
+// per host, most specific first. Merged files fall back to the file each row
+// came from.
+var hostColumnNames = []string{"host", "hostname", "host name", "computer", "computername", "machine", "device", "node", sourceColumnName}
+
+func isXLSXPath(path string) bool {
+	return strings.EqualFold(filepath.Ext(path), ".xlsx")
+}
+
+// hostColumn finds the column naming the host a row came from, or -1.
+func (m *model) hostColumn() int {
+	for _, name := range hostColumnNames {
+		if i := findColumnIndex(m.data.header, name); i >= 0 {
+			return i
+		}
+	}
+	return -1
+}
+
+// exportXLSX writes the rows opts picks to a workbook: a Rows sheet filled by
+// mark colour with comments as a column and as notes, and a Summary sheet
+// counting marks per host. The delimiter and header options only apply to
+// CSV; the sheet always has its header row, frozen.
+func exportXLSX(m *model, path string, opts dialogs.ExportOptions, indices []int) error {
+	f := excelize.NewFile()
+	defer f.Close()
+
+	if err := f.SetSheetName("Sheet1", xlsxRowsSheet); err != nil {
+		return fmt.Errorf("xlsx: %w", err)
+	}
+	if err := m.writeXLSXRows(f, opts, indices); err != nil {
+		return fmt.Errorf("xlsx rows: %w", err)
+	}
+	if err := m.writeXLSXSummary(f, indices); err != nil {
+		return fmt.Errorf("xlsx summary: %w", err)
+	}
+
+	buf, err := f.WriteToBuffer()
+	if err != nil {
+		return fmt.Errorf("xlsx: %w", err)
+	}
+	return writeOutputFile(path, buf.Bytes())
+}
+
+// xlsxMaxRows is the most rows an Excel sheet holds, the header included.
+const xlsxMaxRows = excelize.TotalRows
+
+// xlsxExactDigits is how long an integer can be before Excel rounds it; longer
+// ones, such as IDs, stay text.
+const xlsxExactDigits = 15
+
+// exportTypes lines up column types with exportHeader, so numeric columns go
+// into the sheet as numbers.
+func (m *model) exportTypes(opts dialogs.ExportOptions, cols []int) []ColumnType {
+	types := make([]ColumnType, 0, len(cols)+4)
+	if opts.LineNumber {
+		types = append(types, TypeInt)
+	}
+	if opts.ISOTime {
+		types = append(types, TypeText)
+	}
+	for _, i := range cols {
+		types = append(types, m.data.header[i].Type)
+	}
+	return append(types, TypeText, TypeText)
+}
+
+// xlsxValue is v as the sheet should hold it: a number for int and float
+// columns when it parses, otherwise the text as exported.
+func xlsxValue(t ColumnType, v string) interface{} {
+	s := strings.TrimSpace(v)
+	switch t {
+	case TypeInt:
+		digits := strings.TrimLeft(s, "+-")
+		if n, err := strconv.ParseInt(s, 10, 64); err == nil && len(digits) <= xlsxExactDigits {
+			return n
+		}
+		fallthrough
+	case TypeFloat:
+		if f, err := strconv.ParseFloat(s, 64); err == nil && !math.IsInf(f, 0) && !math.IsNaN(f) {
+			return f
+		}
+	}
+	return v
+}
+
+// writeXLSXRows streams the rows into their sheet, so a large export is not
+// held in memory cell by cell.
+func (m *model) writeXLSXRows(f *excelize.File, opts dialogs.ExportOptions, indices []int) error {
+	if len(indices)+1 > xlsxMaxRows {
+		return fmt.Errorf("%d rows do not fit in a sheet (at most %d besides the header); narrow the export or write CSV", len(indices), xlsxMaxRows-1)
+	}
+	cols := m.exportColumns(opts.VisibleOnly)
+	header := m.exportHeader(opts, cols)
+	types := m.exportTypes(opts, cols)
+	last, err := excelize.ColumnNumberToName(len(header))
+	if err != nil {
+		return err
+	}
+
+	bold, err := f.NewStyle(&excelize.Style{
+		Font: &excelize.Font{Bold: true},
+		Fill: excelize.Fill{Type: "pattern", Pattern: 1, Color: []string{"F2F2F2"}},
+	})
+	if err != nil {
+		return err
+	}
+	fills := make(map[MarkColor]int, len(xlsxFills))
+	for c, rgb := range xlsxFills {
+		if fills[c], err = f.NewStyle(&excelize.Style{
+			Fill: excelize.Fill{Type: "pattern", Pattern: 1, Color: []string{rgb}},
+		}); err != nil {
+			return err
+		}
+	}
+
+	sw, err := f.NewStreamWriter(xlsxRowsSheet)
+	if err != nil {
+		return err
+	}
+	// panes go before the first row
+	if err := sw.SetPanes(&excelize.Panes{
+		Freeze:      true,
+		YSplit:      1,
+		TopLeftCell: "A2",
+		ActivePane:  "bottomLeft",
+	}); err != nil {
+		return err
+	}
+
+	cells := make([]interface{}, len(header))
+	for i, name := range header {
+		cells[i] = excelize.Cell{StyleID: bold, Value: name}
+	}
+	if err := sw.SetRow("A1", cells); err != nil {
+		return err
+	}
+
+	n := 1
+	for _, idx := range indices {
+		if idx < 0 || idx >= len(m.data.rows) {
+			return fmt.Errorf("filtered index %d out of range", idx)
+		}
+		n++
+		r := m.data.rows[idx]
+		style := fills[m.data.markedRows[r.id]] // 0, the default, when unmarked
+		for i, v := range m.exportRecord(idx, opts, cols) {
+			cells[i] = excelize.Cell{StyleID: style, Value: xlsxValue(types[i], v)}
+		}
+		first := fmt.Sprintf("A%d", n)
+		if err := sw.SetRow(first, cells); err != nil {
+			return err
+		}
+		// notes live outside the sheet data, so they can be added while streaming
+		if c := m.data.commentRows[r.id]; c != "" {
+			if err := f.AddComment(xlsxRowsSheet, excelize.Comment{
+				Cell:      first,
+				Author:    xlsxNoteAuthor,
+				Paragraph: []excelize.RichTextRun{{Text: c}},
+			}); err != nil {
+				return err
+			}
+		}
+	}
+
+	if err := f.AutoFilter(xlsxRowsSheet, fmt.Sprintf("A1:%s%d", last, n), nil); err != nil {
+		return err
+	}
+	return sw.Flush()
+}
+
+// xlsxHostCounts are the marks and comments on one host's rows.
+type xlsxHostCounts struct {
+	host                    string
+	red, amber, green, rows int
+	comments                int
+}
+
+func (m *model) writeXLSXSummary(f *excelize.File, indices []int) error {
+	if _, err := f.NewSheet(xlsxSummarySheet); err != nil {
+		return err
+	}
+	hostCol := m.hostColumn()
+	hostLabel := "Host"
+	if hostCol >= 0 {
+		hostLabel = m.data.header[hostCol].Name
+	}
+
+	byHost := make(map[string]*xlsxHostCounts)
+	total := xlsxHostCounts{host: "Total"}
+	for _, idx := range indices {
+		r := m.data.rows[idx]
+		host := "(all rows)"
+		if hostCol >= 0 {
+			host = "(none)"
+			if hostCol < len(r.cols) && r.cols[hostCol] != "" {
+				host = r.cols[hostCol]
+			}
+		}
+		h := byHost[host]
+		if h == nil {
+			h = &xlsxHostCounts{host: host}
+			byHost[host] = h
+		}
+		for _, c := range []*xlsxHostCounts{h, &total} {
+			c.rows++
+			switch m.data.markedRows[r.id] {
+			case MarkRed:
+				c.red++
+			case MarkAmber:
+				c.amber++
+			case MarkGreen:
+				c.green++
+			}
+			if m.data.commentRows[r.id] != "" {
+				c.comments++
+			}
+		}
+	}
+
+	hosts := make([]*xlsxHostCounts, 0, len(byHost))
+	for _, h := range byHost {
+		hosts = append(hosts, h)
+	}
+	// most red marks first, then amber, then green, then by name
+	sort.Slice(hosts, func(a, b int) bool {
+		ha, hb := hosts[a], hosts[b]
+		if ha.red != hb.red {
+			return ha.red > hb.red
+		}
+		if ha.amber != hb.amber {
+			return ha.amber > hb.amber
+		}
+		if ha.green != hb.green {
+			return ha.green > hb.green
+		}
+		return ha.host < hb.host
+	})
+
+	header := []interface{}{hostLabel, "Red", "Amber", "Green", "Marked", "Comments", "Rows"}
+	if err := f.SetSheetRow(xlsxSummarySheet, "A1", &header); err != nil {
+		return err
+	}
+	row := func(n int, c *xlsxHostCounts) error {
+		cells := []interface{}{c.host, c.red, c.amber, c.green, c.red + c.amber + c.green, c.comments, c.rows}
+		return f.SetSheetRow(xlsxSummarySheet, fmt.Sprintf("A%d", n), &cells)
+	}
+	n := 1
+	for _, h := range hosts {
+		n++
+		if err := row(n, h); err != nil {
+			return err
+		}
+	}
+	n++
+	if err := row(n, &total); err != nil {
+		return err
+	}
+
+	bold, err := f.NewStyle(&excelize.Style{Font: &excelize.Font{Bold: true}})
+	if err != nil {
+		return err
+	}
+	if err := f.SetCellStyle(xlsxSummarySheet, "A1", "G1", bold); err != nil {
+		return err
+	}
+	if err := f.SetCellStyle(xlsxSummarySheet, fmt.Sprintf("A%d", n), fmt.Sprintf("G%d", n), bold); err != nil {
+		return err
+	}
+	for c, rgb := range map[string]string{"B": xlsxFills[MarkRed], "C": xlsxFills[MarkAmber], "D": xlsxFills[MarkGreen]} {
+		style, err := f.NewStyle(&excelize.Style{
+			Font: &excelize.Font{Bold: true},
+			Fill: excelize.Fill{Type: "pattern", Pattern: 1, Color: []string{rgb}},
+		})
+		if err != nil {
+			return err
+		}
+		if err := f.SetCellStyle(xlsxSummarySheet, c+"1", c+"1", style); err != nil {
+			return err
+		}
+	}
+	return f.SetColWidth(xlsxSummarySheet, "A", "A", 30)
+}
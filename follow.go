@@ -0,0 +1,93 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sync/atomic"
+	"time"
+
+	"github.com/andareed/siftly-hostlog/logging"
+)
+
+const followPollInterval = 500 * time.Millisecond
+
+var errFileTruncated = errors.New("file was truncated or replaced")
+
+// tailReader reads a file that is still being written. Until following is
+// switched on it behaves like the file itself; afterwards EOF turns into a
+// wait for more data, calling onIdle each time it catches up.
+type tailReader struct {
+	f         *os.File
+	poll      time.Duration
+	following atomic.Bool
+	onIdle    func()
+}
+
+func (t *tailReader) Read(p []byte) (int, error) {
+	for {
+		n, err := t.f.Read(p)
+		if n > 0 || err != io.EOF || !t.following.Load() {
+			return n, err
+		}
+		if t.onIdle != nil {
+			t.onIdle()
+		}
+		time.Sleep(t.poll)
+		if err := t.checkTruncated(); err != nil {
+			return 0, err
+		}
+	}
+}
+
+// checkTruncated catches log rotation: a file shorter than what has already
+// been read cannot be followed any further.
+func (t *tailReader) checkTruncated() error {
+	info, err := t.f.Stat()
+	if err != nil {
+		return err
+	}
+	pos, err := t.f.Seek(0, io.SeekCurrent)
+	if err != nil {
+		return err
+	}
+	if info.Size() < pos {
+		logging.Warnf("tailReader: %s shrank from %d to %d bytes", t.f.Name(), pos, info.Size())
+		return errFileTruncated
+	}
+	return nil
+}
+
+// openFollowInput opens path for --follow. Compressed files cannot grow in a
+// readable way, so they are rejected.
+func openFollowInput(path string) (*inputFile, *tailReader, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, nil, err
+	}
+	tail := &tailReader{f: f, poll: followPollInterval}
+	in := &inputFile{closers: []io.Closer{f}}
+	if info, err := f.Stat(); err == nil {
+		in.size = info.Size()
+		in.modTime = info.ModTime()
+	}
+	if err := in.wrap(tail); err != nil {
+		in.Close()
+		return nil, nil, fmt.Errorf("%s: %w", path, err)
+	}
+	if in.compression != compressionNone {
+		in.Close()
+		return nil, nil, fmt.Errorf("%s: --follow needs an uncompressed file (content is %s)", path, in.compression)
+	}
+	return in, tail, nil
+}
+
+// followingBottom reports whether the cursor sits on the last visible row,
+// in which case it should ride along as new rows arrive.
+func (m *model) followingBottom() bool {
+	if m.load == nil || !m.load.caughtUp || m.load.stream.tail == nil {
+		return false
+	}
+	return m.cursor >= 0 && m.cursor == len(m.data.filteredIndices)-1
+}
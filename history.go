@@ -0,0 +1,153 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/andareed/siftly-hostlog/logging"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// historyLimit caps how many edits can be undone; the oldest are dropped.
+const historyLimit = 200
+
+type editKind int
+
+const (
+	editMark editKind = iota
+	editComment
+	editFilter
+	editTimeWindow
+)
+
+// editValue is the state one edit changed, before or after it. Only the
+// field matching the edit's kind is used.
+type editValue struct {
+	mark    MarkColor
+	comment string
+	filter  string
+	window  TimeWindow
+}
+
+// edit is one undoable change to the model.
+type edit struct {
+	kind   editKind
+	rowID  uint64 // row the cursor was on, and the row marked or commented
+	line   int    // that row's line number, for the notice
+	before editValue
+	after  editValue
+}
+
+func (e edit) describe() string {
+	switch e.kind {
+	case editMark:
+		return fmt.Sprintf("mark on row %d", e.line)
+	case editComment:
+		return fmt.Sprintf("comment on row %d", e.line)
+	case editFilter:
+		return "filter change"
+	default:
+		return "time window change"
+	}
+}
+
+// editHistory holds the edits made this session; steps[:pos] can be undone
+// and steps[pos:] redone.
+type editHistory struct {
+	steps []edit
+	pos   int
+}
+
+// recordEdit adds an edit of kind made at rowIdx, an index into m.data.rows
+// or -1, to the history, dropping anything that could have been redone.
+// Edits that changed nothing are not kept.
+func (m *model) recordEdit(kind editKind, rowIdx int, before, after editValue) {
+	m.touchSession()
+	if before == after {
+		return
+	}
+	e := edit{kind: kind, before: before, after: after}
+	if rowIdx >= 0 && rowIdx < len(m.data.rows) {
+		e.rowID, e.line = m.data.rows[rowIdx].id, m.data.rows[rowIdx].originalIndex
+	}
+	h := &m.history
+	h.steps = append(h.steps[:h.pos], e)
+	if len(h.steps) > historyLimit {
+		h.steps = h.steps[len(h.steps)-historyLimit:]
+	}
+	h.pos = len(h.steps)
+	logging.Debugf("recordEdit: %s, %d step(s) in history", e.describe(), h.pos)
+}
+
+// recordWindowEdit records a change of the time window from before to the
+// current one.
+func (m *model) recordWindowEdit(before TimeWindow) {
+	m.recordEdit(editTimeWindow, m.cursorRowIndex(), editValue{window: before}, editValue{window: m.data.timeWindow})
+}
+
+// cursorRowIndex is the index into m.data.rows of the row under the cursor,
+// or -1 when there is none.
+func (m *model) cursorRowIndex() int {
+	if m.cursor < 0 || m.cursor >= len(m.data.filteredIndices) {
+		return -1
+	}
+	return m.data.filteredIndices[m.cursor]
+}
+
+func (m *model) undo() tea.Cmd {
+	h := &m.history
+	if h.pos == 0 {
+		return m.startNotice("Nothing to undo", "warn", noticeDuration)
+	}
+	h.pos--
+	e := h.steps[h.pos]
+	m.applyEdit(e, e.before)
+	return m.startNotice(fmt.Sprintf("Undid %s (%d more)", e.describe(), h.pos), "", noticeDuration)
+}
+
+func (m *model) redo() tea.Cmd {
+	h := &m.history
+	if h.pos == len(h.steps) {
+		return m.startNotice("Nothing to redo", "warn", noticeDuration)
+	}
+	e := h.steps[h.pos]
+	h.pos++
+	m.applyEdit(e, e.after)
+	return m.startNotice(fmt.Sprintf("Redid %s (%d more)", e.describe(), len(h.steps)-h.pos), "", noticeDuration)
+}
+
+// applyEdit puts back one side of an edit and returns the cursor to the row
+// it was made on.
+func (m *model) applyEdit(e edit, v editValue) {
+	m.touchSession()
+	switch e.kind {
+	case editMark:
+		if v.mark == MarkNone {
+			delete(m.data.markedRows, e.rowID)
+		} else {
+			m.data.markedRows[e.rowID] = v.mark
+		}
+		if m.data.showOnlyMarked {
+			m.applyFilter()
+		}
+	case editComment:
+		if v.comment == "" {
+			delete(m.data.commentRows, e.rowID)
+		} else {
+			m.data.commentRows[e.rowID] = v.comment
+		}
+	case editFilter:
+		if err := m.compileFilter(v.filter); err != nil {
+			logging.Warnf("applyEdit: filter %q no longer compiles: %v", v.filter, err)
+		}
+		m.applyFilter()
+	case editTimeWindow:
+		m.data.timeWindow = v.window
+		m.applyFilter()
+	}
+	if e.rowID != 0 {
+		m.jumpToHashID(e.rowID)
+	}
+	if m.ready {
+		m.refreshView("undo", false)
+	}
+}
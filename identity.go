@@ -0,0 +1,60 @@
+package main
+
+import (
+	"strings"
+
+	"github.com/andareed/siftly-hostlog/logging"
+)
+
+// defaultIDColumns come from the config file; nil keys rows on every column.
+var defaultIDColumns []string
+
+// parseColumnList splits "Time,Host,Details" or "Time+Host+Details".
+func parseColumnList(s string) []string {
+	var names []string
+	for _, name := range strings.FieldsFunc(s, func(r rune) bool { return r == ',' || r == '+' }) {
+		if name = strings.TrimSpace(name); name != "" {
+			names = append(names, name)
+		}
+	}
+	return names
+}
+
+// identityColumns picks the columns a load keys its rows on: --id-cols, then
+// the parser profile's, then the config file's.
+func identityColumns(opts loadOptions, p *parserProfile) []string {
+	switch {
+	case len(opts.idColumns) > 0:
+		return opts.idColumns
+	case p != nil && len(p.IDColumns) > 0:
+		return p.IDColumns
+	}
+	return defaultIDColumns
+}
+
+// resolveIDColumns finds the named identity columns in header. Names the
+// header lacks are returned in missing; if none are found the result is nil
+// and rows are keyed on every column.
+func resolveIDColumns(header []ColumnMeta, names []string) (idx []int, missing []string) {
+	for _, name := range names {
+		i := findColumnIndex(header, name)
+		if i < 0 {
+			missing = append(missing, name)
+			continue
+		}
+		idx = append(idx, i)
+	}
+	return idx, missing
+}
+
+// idColumnIndices resolves the model's identity columns against its header.
+func (m *model) idColumnIndices() []int {
+	idx, missing := resolveIDColumns(m.data.header, m.data.idColumns)
+	switch {
+	case len(missing) > 0 && len(idx) == 0:
+		logging.Warnf("identity columns %v not in the header, keying rows on every column", missing)
+	case len(missing) > 0:
+		logging.Warnf("identity columns %v not in the header, keying rows on the other %d", missing, len(idx))
+	}
+	return idx
+}
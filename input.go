@@ -0,0 +1,352 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"time"
+
+	"github.com/andareed/siftly-hostlog/logging"
+	"github.com/klauspost/compress/zstd"
+)
+
+type compression int
+
+const (
+	compressionNone compression = iota
+	compressionGzip
+	compressionZstd
+)
+
+var (
+	gzipMagic = []byte{0x1f, 0x8b}
+	zstdMagic = []byte{0x28, 0xb5, 0x2f, 0xfd}
+)
+
+func (c compression) String() string {
+	switch c {
+	case compressionGzip:
+		return "gzip"
+	case compressionZstd:
+		return "zstd"
+	default:
+		return "none"
+	}
+}
+
+// compressionFromExt reports the compression implied by a file name.
+func compressionFromExt(path string) compression {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".gz", ".gzip":
+		return compressionGzip
+	case ".zst", ".zstd":
+		return compressionZstd
+	default:
+		return compressionNone
+	}
+}
+
+// trimCompressionExt strips a trailing .gz/.zst so the inner format can be
+// determined, e.g. "hostlog.csv.gz" -> "hostlog.csv".
+func trimCompressionExt(path string) string {
+	if compressionFromExt(path) == compressionNone {
+		return path
+	}
+	return strings.TrimSuffix(path, filepath.Ext(path))
+}
+
+// sniffCompression looks at the leading bytes; the content wins over the extension.
+func sniffCompression(head []byte) compression {
+	switch {
+	case bytes.HasPrefix(head, gzipMagic):
+		return compressionGzip
+	case bytes.HasPrefix(head, zstdMagic):
+		return compressionZstd
+	default:
+		return compressionNone
+	}
+}
+
+// inputFile is an opened input with any compression already unwrapped.
+// counter sees the raw (compressed) bytes so progress can be measured
+// against the size on disk.
+type inputFile struct {
+	*bufio.Reader
+	counter     *countingReader
+	path        string    // file it was opened from, "" for stdin
+	size        int64     // size on disk, 0 when unknown
+	modTime     time.Time // last written, zero when unknown
+	compression compression
+	encoding    string // set once decode has run
+	closers     []io.Closer
+}
+
+func (in *inputFile) Close() error {
+	var first error
+	for i := len(in.closers) - 1; i >= 0; i-- {
+		if err := in.closers[i].Close(); err != nil && first == nil {
+			first = err
+		}
+	}
+	return first
+}
+
+// openInput opens path for reading, decompressing gzip and zstd content as it is read.
+func openInput(path string) (*inputFile, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	in := &inputFile{path: path, closers: []io.Closer{f}}
+	if info, err := f.Stat(); err == nil {
+		in.size = info.Size()
+		in.modTime = info.ModTime()
+	}
+	if err := in.wrap(f); err != nil {
+		in.Close()
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	if ext := compressionFromExt(path); ext != compressionNone && ext != in.compression {
+		logging.Warnf("openInput: %s has a %s extension but the content is %s, going by the content", path, ext, in.compression)
+	}
+	return in, nil
+}
+
+// wrap installs the byte counter and, when the magic bytes match, a decompressor.
+func (in *inputFile) wrap(r io.Reader) error {
+	in.counter = &countingReader{r: r}
+	br := bufio.NewReader(in.counter)
+	head, _ := br.Peek(len(zstdMagic))
+
+	in.compression = sniffCompression(head)
+	switch in.compression {
+	case compressionGzip:
+		zr, err := gzip.NewReader(br)
+		if err != nil {
+			return fmt.Errorf("open gzip stream: %w", err)
+		}
+		in.closers = append(in.closers, zr)
+		in.Reader = bufio.NewReader(zr)
+	case compressionZstd:
+		zr, err := zstd.NewReader(br)
+		if err != nil {
+			return fmt.Errorf("open zstd stream: %w", err)
+		}
+		in.closers = append(in.closers, zr.IOReadCloser())
+		in.Reader = bufio.NewReader(zr)
+	default:
+		in.Reader = br
+	}
+	return nil
+}
+
+// openStdin treats standard input like any other input, compression included.
+func openStdin() (*inputFile, error) {
+	in := &inputFile{}
+	if err := in.wrap(os.Stdin); err != nil {
+		return nil, fmt.Errorf("stdin: %w", err)
+	}
+	return in, nil
+}
+
+// stdinIsPiped reports whether standard input is a pipe or file rather than a terminal.
+func stdinIsPiped() bool {
+	info, err := os.Stdin.Stat()
+	if err != nil {
+		return false
+	}
+	return info.Mode()&os.ModeCharDevice == 0
+}
+
+type inputFormat int
+
+const (
+	formatUnknown inputFormat = iota
+	formatCSV
+	formatSnapshot
+	formatJSONL
+)
+
+const sniffSize = 4096
+
+var utf8BOM = []byte{0xef, 0xbb, 0xbf}
+
+// sniffFormat guesses the format from the (decompressed) head of the input.
+// Snapshots are JSON objects with a numeric "version" and the "rows" array,
+// or the "source" object when they refer to the file the rows came from, at
+// the top level. A first line that is any other JSON object, or one cut off
+// at the sniff limit, means JSON Lines. Anything else is treated as CSV.
+func sniffFormat(head []byte) inputFormat {
+	cut := len(head) >= sniffSize
+	trimmed := bytes.TrimLeft(bytes.TrimPrefix(head, utf8BOM), " \t\r\n")
+	if len(trimmed) == 0 {
+		return formatUnknown
+	}
+	if trimmed[0] != '{' {
+		return formatCSV
+	}
+
+	line := trimmed
+	nl := bytes.IndexByte(line, '\n')
+	if nl >= 0 {
+		line = line[:nl]
+	}
+	if keys, ok := topLevelKeys(line); ok || (nl < 0 && cut) {
+		if isSnapshotHead(keys) {
+			return formatSnapshot // a snapshot saved without indentation
+		}
+		return formatJSONL
+	}
+	if keys, _ := topLevelKeys(trimmed); isSnapshotHead(keys) {
+		return formatSnapshot
+	}
+	return formatUnknown
+}
+
+// isSnapshotHead reports whether the top-level keys of a JSON object, mapped
+// to the first byte of their values, are a snapshot's. A version 1 header
+// can run past the sniff limit before "rows", so a header array counts too.
+func isSnapshotHead(keys map[string]byte) bool {
+	if v := keys["version"]; v != '-' && (v < '0' || v > '9') {
+		return false
+	}
+	return keys["rows"] == '[' || keys["source"] == '{' || keys["header"] == '['
+}
+
+// topLevelKeys collects the keys of the JSON object at the start of data,
+// each with the first byte of its value, reporting whether the object was
+// complete. A head cut off mid-object still yields the keys seen so far.
+func topLevelKeys(data []byte) (map[string]byte, bool) {
+	keys := map[string]byte{}
+	dec := json.NewDecoder(bytes.NewReader(data))
+	if tok, err := dec.Token(); err != nil || tok != json.Delim('{') {
+		return keys, false
+	}
+	for dec.More() {
+		tok, err := dec.Token()
+		if err != nil {
+			return keys, false
+		}
+		key, ok := tok.(string)
+		if !ok {
+			return keys, false
+		}
+		if rest := bytes.TrimLeft(data[dec.InputOffset():], " \t\r\n:"); len(rest) > 0 {
+			keys[key] = rest[0]
+		}
+		var skip json.RawMessage
+		if err := dec.Decode(&skip); err != nil {
+			return keys, false
+		}
+	}
+	if _, err := dec.Token(); err != nil {
+		return keys, false
+	}
+	return keys, true
+}
+
+// sniff peeks at the start of the input without consuming it.
+func (in *inputFile) sniff() inputFormat {
+	head, _ := in.Peek(sniffSize)
+	return sniffFormat(head)
+}
+
+// readInputFile reads the whole of path, decompressing if needed.
+func readInputFile(path string) ([]byte, error) {
+	in, err := openInput(path)
+	if err != nil {
+		return nil, err
+	}
+	defer in.Close()
+	return io.ReadAll(in)
+}
+
+// writeOutputFile writes data to path, compressing it when the extension asks
+// for it. The data goes to a temporary file beside path that is then renamed
+// over it, so a crash part-way through leaves the old file intact.
+func writeOutputFile(path string, data []byte) error {
+	switch compressionFromExt(path) {
+	case compressionGzip:
+		var buf bytes.Buffer
+		zw := gzip.NewWriter(&buf)
+		if _, err := zw.Write(data); err != nil {
+			return err
+		}
+		if err := zw.Close(); err != nil {
+			return err
+		}
+		data = buf.Bytes()
+	case compressionZstd:
+		zw, err := zstd.NewWriter(nil)
+		if err != nil {
+			return err
+		}
+		data = zw.EncodeAll(data, nil)
+		zw.Close()
+	}
+	return writeFileAtomic(path, data)
+}
+
+// writeFileAtomic writes data to a temporary file beside path and renames it
+// into place, so a crash leaves either the old file or the new one. The file
+// keeps the mode of the one it replaces, 0644 when it is new.
+func writeFileAtomic(path string, data []byte) error {
+	mode := fs.FileMode(0o644)
+	if info, err := os.Stat(path); err == nil {
+		mode = info.Mode().Perm()
+	}
+	dir := filepath.Dir(path)
+	tmp, err := os.CreateTemp(dir, "."+filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	if err := tmp.Chmod(mode); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return err
+	}
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		os.Remove(tmp.Name())
+		return fmt.Errorf("replace %s: %w", path, err)
+	}
+	return syncDir(dir)
+}
+
+// syncDir flushes a directory so a rename in it survives a crash. Windows
+// cannot sync a directory handle and commits renames itself.
+func syncDir(dir string) error {
+	if runtime.GOOS == "windows" {
+		return nil
+	}
+	d, err := os.Open(dir)
+	if err != nil {
+		return err
+	}
+	defer d.Close()
+	if err := d.Sync(); err != nil {
+		return fmt.Errorf("sync %s: %w", dir, err)
+	}
+	return nil
+}
@@ -0,0 +1,236 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/andareed/siftly-hostlog/logging"
+)
+
+// defaultTimeKeys are key names taken as a hint that a key holds the
+// timestamp when no --time-key is given; see detectTimeColumn.
+var defaultTimeKeys = []string{"time", "timestamp", "@timestamp", "ts", "datetime", "date"}
+
+// jsonlSource reads one JSON object per line. Nested objects are flattened
+// into dotted keys ("http.status"), arrays are kept as compact JSON, and the
+// header is the union of every key seen, in order of first appearance. Keys
+// first seen after the header was handed out are reported via takeColumns;
+// records are always as wide as the header is at the time they are read.
+type jsonlSource struct {
+	r          *bufio.Reader
+	keys       []string
+	index      map[string]int
+	added      []string // keys not yet reported through takeColumns
+	headerSent bool
+	first      []string // record read while building the header
+	line       int
+	issues     []loadIssue
+}
+
+func newJSONLSource(r *bufio.Reader) *jsonlSource {
+	return &jsonlSource{r: r, index: map[string]int{}}
+}
+
+// columnSource is implemented by record sources whose header can grow mid-stream.
+type columnSource interface {
+	takeColumns() []string
+}
+
+func (s *jsonlSource) takeColumns() []string {
+	added := s.added
+	s.added = nil
+	return added
+}
+
+// Lines that are not JSON objects cannot be placed in any column, so they
+// are always skipped; repaired is never set.
+func (s *jsonlSource) lastRepaired() bool { return false }
+
+func (s *jsonlSource) takeIssues() []loadIssue {
+	issues := s.issues
+	s.issues = nil
+	return issues
+}
+
+func (s *jsonlSource) Read() ([]string, error) {
+	if !s.headerSent {
+		rec, err := s.next()
+		if err != nil {
+			return nil, err
+		}
+		s.headerSent = true
+		s.first = rec
+		s.added = nil
+		return append([]string(nil), s.keys...), nil
+	}
+	if s.first != nil {
+		rec := s.first
+		s.first = nil
+		return rec, nil
+	}
+	return s.next()
+}
+
+// next reads lines until one holds a JSON object and returns it as a record.
+func (s *jsonlSource) next() ([]string, error) {
+	for {
+		text, err := readLine(s.r)
+		if err != nil {
+			return nil, err
+		}
+		s.line++
+		if strings.TrimSpace(text) == "" {
+			continue
+		}
+
+		fields := map[string]string{}
+		var order []string
+		err = flattenJSON([]byte(text), "", func(key, value string) {
+			if _, dup := fields[key]; !dup {
+				order = append(order, key)
+			}
+			fields[key] = value
+		})
+		if err != nil {
+			s.issues = append(s.issues, loadIssue{Line: s.line, Skipped: true, Detail: err.Error()})
+			continue
+		}
+
+		for _, key := range order {
+			if _, ok := s.index[key]; !ok {
+				s.index[key] = len(s.keys)
+				s.keys = append(s.keys, key)
+				s.added = append(s.added, key)
+			}
+		}
+		rec := make([]string, len(s.keys))
+		for key, value := range fields {
+			rec[s.index[key]] = value
+		}
+		return rec, nil
+	}
+}
+
+// flattenJSON walks a single JSON object, calling emit for every leaf value
+// with its dotted key. Key order follows the document.
+func flattenJSON(data []byte, prefix string, emit func(key, value string)) error {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	tok, err := dec.Token()
+	if err != nil {
+		return fmt.Errorf("not valid JSON: %w", err)
+	}
+	if tok != json.Delim('{') {
+		return errors.New("not a JSON object")
+	}
+	for dec.More() {
+		tok, err := dec.Token()
+		if err != nil {
+			return fmt.Errorf("not valid JSON: %w", err)
+		}
+		key := prefix + tok.(string)
+
+		var raw json.RawMessage
+		if err := dec.Decode(&raw); err != nil {
+			return fmt.Errorf("not valid JSON: %w", err)
+		}
+		switch raw[0] {
+		case '{':
+			if err := flattenJSON(raw, key+".", emit); err != nil {
+				return err
+			}
+		case '[':
+			var buf bytes.Buffer
+			if err := json.Compact(&buf, raw); err != nil {
+				return fmt.Errorf("not valid JSON: %w", err)
+			}
+			emit(key, buf.String())
+		case '"':
+			var s string
+			if err := json.Unmarshal(raw, &s); err != nil {
+				return fmt.Errorf("not valid JSON: %w", err)
+			}
+			emit(key, s)
+		case 'n':
+			emit(key, "")
+		default:
+			emit(key, string(raw))
+		}
+	}
+	if _, err := dec.Token(); err != nil {
+		return fmt.Errorf("not valid JSON: %w", err)
+	}
+	if _, err := dec.Token(); err != io.EOF {
+		return errors.New("trailing data after JSON object")
+	}
+	return nil
+}
+
+// newModelFromJSONL loads a JSON Lines file. The header grows as new keys
+// turn up; see jsonlSource.
+func newModelFromJSONL(in *inputFile, name string, tail *tailReader, opts loadOptions) (*model, error) {
+	src := newJSONLSource(in.Reader)
+	keys, err := src.Read()
+	if err == io.EOF {
+		in.Close()
+		return nil, fmt.Errorf("%s has no JSON records", name)
+	}
+	if err != nil {
+		in.Close()
+		return nil, fmt.Errorf("error reading %s: %w", name, err)
+	}
+
+	m := initialModelFromHeader(keys)
+	m.InitialPath = name
+	m.data.timeColumn = opts.timeKey
+	logging.Infof("newModelFromJSONL: %d keys in the first record, time key %q", len(keys), m.data.timeColumn)
+	if err := m.startSource(in, src, tail, opts); err != nil {
+		return nil, fmt.Errorf("error reading %s: %w", name, err)
+	}
+	m.InitialiseUI()
+	return m, nil
+}
+
+func newModelFromJSONLFile(path string, opts loadOptions) (*model, error) {
+	in, err := openInput(path)
+	if err != nil {
+		return nil, fmt.Errorf("error opening file: %w", err)
+	}
+	if err := in.decode(opts.encoding); err != nil {
+		in.Close()
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return newModelFromJSONL(in, path, nil, opts)
+}
+
+// addColumns appends newly discovered columns to the header and pads rows
+// read before they existed. Row IDs stay as they were: trailing empty cells
+// are not part of them.
+func (m *model) addColumns(names []string) {
+	if len(names) == 0 {
+		return
+	}
+	for _, name := range names {
+		m.data.header = append(m.data.header, newColumnMeta(len(m.data.header), name))
+	}
+	for i := range m.data.rows {
+		m.padRow(&m.data.rows[i])
+	}
+
+	// The time key may only turn up once the first records are in
+	if m.data.timeColumnIndex < 0 {
+		m.computeTimeBounds()
+	}
+}
+
+// padRow widens a row read before the header last grew.
+func (m *model) padRow(row *renderedRow) {
+	for len(row.cols) < len(m.data.header) {
+		row.cols = append(row.cols, "")
+	}
+}
@@ -34,6 +34,13 @@ type Keymap struct {
 	TimeWindowStart key.Binding
 	TimeWindowEnd   key.Binding
 	TimeWindowReset key.Binding
+	Diagnostics     key.Binding
+	DisplayZone     key.Binding
+	ColumnLeft      key.Binding
+	ColumnRight     key.Binding
+	Sort            key.Binding
+	Undo            key.Binding
+	Redo            key.Binding
 }
 
 var Keys = Keymap{
@@ -153,6 +160,34 @@ var Keys = Keymap{
 		key.WithKeys("T"),
 		key.WithHelp("T", "Reset time window"),
 	),
+	Diagnostics: key.NewBinding(
+		key.WithKeys("D"),
+		key.WithHelp("D", "Load diagnostics"),
+	),
+	DisplayZone: key.NewBinding(
+		key.WithKeys("Z"),
+		key.WithHelp("Z", "Show times in source/UTC/local zone"),
+	),
+	ColumnLeft: key.NewBinding(
+		key.WithKeys(","),
+		key.WithHelp(",", "Column cursor left"),
+	),
+	ColumnRight: key.NewBinding(
+		key.WithKeys("."),
+		key.WithHelp(".", "Column cursor right"),
+	),
+	Sort: key.NewBinding(
+		key.WithKeys("o"),
+		key.WithHelp("o", "Sort by column: asc/desc/off"),
+	),
+	Undo: key.NewBinding(
+		key.WithKeys("U", "ctrl+z"),
+		key.WithHelp("U/ctrl+z", "Undo mark, comment, filter or time window change"),
+	),
+	Redo: key.NewBinding(
+		key.WithKeys("ctrl+r", "ctrl+y"),
+		key.WithHelp("ctrl+r", "Redo"),
+	),
 }
 
 func (k Keymap) Legend() []key.Binding {
@@ -183,5 +218,12 @@ func (k Keymap) Legend() []key.Binding {
 		k.TimeWindowStart,
 		k.TimeWindowEnd,
 		k.TimeWindowReset,
+		k.Diagnostics,
+		k.DisplayZone,
+		k.ColumnLeft,
+		k.ColumnRight,
+		k.Sort,
+		k.Undo,
+		k.Redo,
 	}
 }
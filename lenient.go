@@ -0,0 +1,223 @@
+package main
+
+import (
+	"bufio"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// maxContinuationLines bounds how far a quoted field may run on before the
+// line it started on is declared malformed.
+const maxContinuationLines = 10
+
+// loadIssue records a line that had to be repaired or skipped in lenient mode.
+type loadIssue struct {
+	Line    int    // physical line in the input, 1-based
+	Row     int    // originalIndex of the row it became, 0 if skipped or unknown
+	Skipped bool   // dropped rather than kept as a flagged row
+	Detail  string // what was wrong and what was done about it
+}
+
+func (i loadIssue) String() string {
+	if i.Skipped {
+		return fmt.Sprintf("line %d: skipped, %s", i.Line, i.Detail)
+	}
+	if i.Row == 0 {
+		return fmt.Sprintf("line %d: %s", i.Line, i.Detail)
+	}
+	return fmt.Sprintf("line %d (row %d): %s", i.Line, i.Row, i.Detail)
+}
+
+// issueSource is implemented by record sources that repair input as they read it.
+type issueSource interface {
+	recordSource
+	lastRepaired() bool
+	takeIssues() []loadIssue
+}
+
+// lenientSource reads CSV one physical line at a time so a single malformed
+// line cannot take the rest of the file down with it. Ragged rows are padded
+// or have their extra fields folded into the last column; lines whose quoting
+// cannot be made sense of are split naively. With skipBad set, any line that
+// needed repair is dropped instead.
+type lenientSource struct {
+	r        *bufio.Reader
+	comma    rune
+	skipBad  bool
+	width    int // header width, 0 until the header has been read
+	line     int // physical lines consumed so far
+	records  int // data records returned so far
+	pushback []string
+	repaired bool
+	issues   []loadIssue
+}
+
+func newLenientSource(r *bufio.Reader, comma rune, skipBad bool) *lenientSource {
+	return &lenientSource{r: r, comma: comma, skipBad: skipBad}
+}
+
+func (s *lenientSource) lastRepaired() bool { return s.repaired }
+
+func (s *lenientSource) takeIssues() []loadIssue {
+	issues := s.issues
+	s.issues = nil
+	return issues
+}
+
+func (s *lenientSource) Read() ([]string, error) {
+	for {
+		text, err := s.nextLine()
+		if err != nil {
+			return nil, err
+		}
+		start := s.line
+		if strings.TrimSpace(text) == "" {
+			continue
+		}
+
+		rec, problem := s.parse(text)
+		if s.width == 0 {
+			// The header sets the width every later row is held to
+			s.width = len(rec)
+			return rec, nil
+		}
+
+		rec, ragged := s.fitWidth(rec)
+		if problem == "" {
+			problem = ragged
+		} else if ragged != "" {
+			problem += ", " + ragged
+		}
+
+		s.repaired = problem != ""
+		if s.repaired && s.skipBad {
+			s.issues = append(s.issues, loadIssue{Line: start, Skipped: true, Detail: problem})
+			continue
+		}
+		s.records++
+		if s.repaired {
+			s.issues = append(s.issues, loadIssue{Line: start, Row: s.records, Detail: problem})
+		}
+		return rec, nil
+	}
+}
+
+// parse turns a line into fields, pulling in continuation lines when a quoted
+// field spans them. It reports what, if anything, it had to work around.
+func (s *lenientSource) parse(text string) ([]string, string) {
+	rec, err := s.parseStrict(text)
+	if err == nil {
+		return rec, ""
+	}
+
+	// An unterminated quote may just be a field with an embedded newline
+	if errors.Is(err, csv.ErrQuote) {
+		joined := text
+		var taken []string
+		for i := 0; i < maxContinuationLines; i++ {
+			next, err := s.nextLine()
+			if err != nil {
+				break
+			}
+			taken = append(taken, next)
+			joined += "\n" + next
+			if rec, err := s.parseStrict(joined); err == nil {
+				return rec, ""
+			}
+		}
+		// Give the lines back; they are rows of their own
+		s.line -= len(taken)
+		s.pushback = append(taken, s.pushback...)
+	}
+
+	lazy := csv.NewReader(strings.NewReader(text))
+	lazy.Comma = s.comma
+	lazy.FieldsPerRecord = -1
+	lazy.LazyQuotes = true
+	if rec, err := lazy.Read(); err == nil {
+		return rec, "stray quotes tolerated"
+	}
+	return strings.Split(text, string(s.comma)), "unparseable quoting, split on delimiter"
+}
+
+func (s *lenientSource) parseStrict(text string) ([]string, error) {
+	r := csv.NewReader(strings.NewReader(text))
+	r.Comma = s.comma
+	r.FieldsPerRecord = -1
+	return r.Read()
+}
+
+// fitWidth pads short records and folds surplus fields into the last column.
+func (s *lenientSource) fitWidth(rec []string) ([]string, string) {
+	switch {
+	case len(rec) < s.width:
+		missing := s.width - len(rec)
+		for len(rec) < s.width {
+			rec = append(rec, "")
+		}
+		return rec, fmt.Sprintf("padded %d missing field(s)", missing)
+	case len(rec) > s.width:
+		extra := len(rec) - s.width
+		last := strings.Join(rec[s.width-1:], string(s.comma))
+		rec = append(rec[:s.width-1], last)
+		return rec, fmt.Sprintf("folded %d extra field(s) into the last column", extra)
+	default:
+		return rec, ""
+	}
+}
+
+func (s *lenientSource) nextLine() (string, error) {
+	if len(s.pushback) > 0 {
+		text := s.pushback[0]
+		s.pushback = s.pushback[1:]
+		s.line++
+		return text, nil
+	}
+	text, err := readLine(s.r)
+	if err != nil {
+		return "", err
+	}
+	s.line++
+	return text, nil
+}
+
+// readLine returns the next line without its terminator. A final line with no
+// newline is still returned; io.EOF only comes once nothing is left.
+func readLine(r *bufio.Reader) (string, error) {
+	text, err := r.ReadString('\n')
+	if text == "" && err != nil {
+		return "", err
+	}
+	if err != nil && err != io.EOF {
+		return "", err
+	}
+	return strings.TrimRight(text, "\r\n"), nil
+}
+
+// newRecordSource picks the strict csv.Reader or, with --lenient, the line-based reader.
+func newRecordSource(in *inputFile, opts loadOptions) recordSource {
+	comma := in.sniffDelimiter(opts.delimiter)
+	if opts.lenient {
+		return newLenientSource(in.Reader, comma, opts.skipBad)
+	}
+	r := csv.NewReader(in)
+	r.Comma = comma
+	return r
+}
+
+// loadDiagnostics summarises the issues gathered so far for the diagnostics dialog.
+func (m *model) loadDiagnostics() (string, []string) {
+	skipped := 0
+	lines := make([]string, len(m.load.issues))
+	for i, issue := range m.load.issues {
+		if issue.Skipped {
+			skipped++
+		}
+		lines[i] = issue.String()
+	}
+	repaired := len(m.load.issues) - skipped
+	return fmt.Sprintf("%d line(s) repaired, %d skipped", repaired, skipped), lines
+}
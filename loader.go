@@ -1,118 +1,286 @@
 package main
 
 import (
-	"encoding/csv"
 	"fmt"
-	"os"
+	"io"
 	"path/filepath"
 	"strings"
+	"time"
 
 	"github.com/andareed/siftly-hostlog/logging"
 )
 
-func loadModelAuto(path string) (*model, error) {
-	ext := strings.ToLower(filepath.Ext(path))
+// stdinPath is the conventional argument for reading from standard input.
+const stdinPath = "-"
+
+// stdinName stands in for a file name when the data came from a pipe; it seeds
+// the default save and export names.
+const stdinName = "stdin"
+
+// loadOptions carries the command line switches that change how input is read.
+type loadOptions struct {
+	follow     bool             // keep reading rows appended to the file after the initial load
+	delimiter  rune             // CSV field separator, 0 to sniff
+	encoding   string           // text encoding, "" to sniff
+	lenient    bool             // repair ragged or badly quoted lines instead of failing
+	skipBad    bool             // in lenient mode, drop bad lines rather than keep them flagged
+	profile    *parserProfile   // parse plain-text lines with this profile, nil to detect
+	profiles   []*parserProfile // candidates for detection
+	timeKey    string           // JSON Lines key holding the timestamp, "" to guess
+	timeLayout string           // timestamp format for this file, "" to try the configured list
+	tz         *time.Location   // zone of timestamps written without one, nil for UTC
+	tzName     string           // tz as given on the command line
+	idColumns  []string         // row identity columns from --id-cols, nil for the profile's or config's
+}
+
+// lenientHint suggests --lenient when a strict load trips over bad input.
+func lenientHint(opts loadOptions) string {
+	if opts.lenient {
+		return ""
+	}
+	return " (re-run with --lenient to load around bad lines)"
+}
+
+// loadModelAuto picks a loader from the extension, looking through any
+// .gz/.zst suffix to the format underneath. Unknown extensions are sniffed,
+// and so is .json, which may hold a snapshot or JSON Lines.
+func loadModelAuto(path string, opts loadOptions) (*model, error) {
+	if opts.follow {
+		return newModelFollowingFile(path, opts)
+	}
+	if path == stdinPath {
+		return loadModelFromStdin(opts)
+	}
+	ext := strings.ToLower(filepath.Ext(trimCompressionExt(path)))
 	switch ext {
-	case ".json":
-		return newModelFromJSONFile(path)
-	case ".csv":
-		return newModelFromCSVFile(path)
-	default:
-		return nil, fmt.Errorf("unsupported file extension %q (want .csv or .json)", ext)
+	case ".csv", ".tsv":
+		return newModelFromCSVFile(path, opts)
+	case ".jsonl", ".ndjson":
+		return newModelFromJSONLFile(path, opts)
+	}
+
+	in, err := openInput(path)
+	if err != nil {
+		return nil, fmt.Errorf("error opening file: %w", err)
 	}
+	return newModelFromInput(in, path, opts)
 }
 
-// Load Data From Serialized JSONs using LoadModel(m, path)
-// Implies that this has been analysed previously and saved
-func newModelFromJSONFile(path string) (*model, error) {
-	m := &model{}
-	if err := LoadModel(m, path); err != nil {
+// loadModelFromStdin reads a CSV or snapshot piped in on standard input.
+func loadModelFromStdin(opts loadOptions) (*model, error) {
+	in, err := openStdin()
+	if err != nil {
 		return nil, err
 	}
-	m.InitialPath = path
-	m.InitialiseUI()
-	return m, nil
+	return newModelFromInput(in, stdinName, opts)
 }
 
-func newModelFromCSVFile(path string) (*model, error) {
-	// ...read csv...
-	f, err := os.Open(path)
+// newModelFromInput sniffs the content of an already-open input and hands it
+// to the matching loader.
+func newModelFromInput(in *inputFile, name string, opts loadOptions) (*model, error) {
+	if err := in.decode(opts.encoding); err != nil {
+		in.Close()
+		return nil, fmt.Errorf("%s: %w", name, err)
+	}
+	if p := pickProfile(in, opts); p != nil {
+		return newModelFromProfile(in, name, nil, p, opts)
+	}
+	switch in.sniff() {
+	case formatSnapshot:
+		defer in.Close()
+		data, err := io.ReadAll(in)
+		if err != nil {
+			return nil, fmt.Errorf("error reading %s: %w", name, err)
+		}
+		m := &model{}
+		dir := "."
+		if in.path != "" {
+			dir = filepath.Dir(in.path)
+		}
+		if err := loadSnapshotData(m, data, dir, opts); err != nil {
+			return nil, err
+		}
+		m.InitialPath = name
+		m.InitialiseUI()
+		return m, nil
+	case formatJSONL:
+		return newModelFromJSONL(in, name, nil, opts)
+	case formatCSV:
+		return newModelFromCSV(in, name, nil, opts)
+	default:
+		in.Close()
+		return nil, fmt.Errorf("%s is empty or not a recognised format (want CSV, JSON Lines, a JSON snapshot or a log matching a --profile)", name)
+	}
+}
+
+// newModelFollowingFile loads a CSV and keeps tailing it for new rows.
+func newModelFollowingFile(path string, opts loadOptions) (*model, error) {
+	if path == stdinPath {
+		return nil, fmt.Errorf("--follow needs a file path, not stdin")
+	}
+	in, tail, err := openFollowInput(path)
 	if err != nil {
 		return nil, fmt.Errorf("error opening file: %w", err)
 	}
-	defer f.Close()
+	if err := in.decode(opts.encoding); err != nil {
+		in.Close()
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	if p := pickProfile(in, opts); p != nil {
+		return newModelFromProfile(in, path, tail, p, opts)
+	}
+	switch in.sniff() {
+	case formatCSV:
+		return newModelFromCSV(in, path, tail, opts)
+	case formatJSONL:
+		return newModelFromJSONL(in, path, tail, opts)
+	default:
+		in.Close()
+		return nil, fmt.Errorf("--follow only supports CSV, JSON Lines and text logs")
+	}
+}
 
-	r := csv.NewReader(f)
-	records, err := r.ReadAll()
+func newModelFromCSVFile(path string, opts loadOptions) (*model, error) {
+	in, err := openInput(path)
 	if err != nil {
-		return nil, fmt.Errorf("error reading CSV: %w", err)
+		return nil, fmt.Errorf("error opening file: %w", err)
 	}
-	if len(records) == 0 {
-		return nil, fmt.Errorf("CSV %q has no rows", path)
+	if err := in.decode(opts.encoding); err != nil {
+		in.Close()
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	if opts.profile != nil {
+		return newModelFromProfile(in, path, nil, opts.profile, opts)
+	}
+	return newModelFromCSV(in, path, nil, opts)
+}
+
+// newModelFromCSV reads the header and the first page of rows up front;
+// the remainder of the input is streamed in by Init once the program starts.
+// tail is only set in --follow mode.
+func newModelFromCSV(in *inputFile, name string, tail *tailReader, opts loadOptions) (*model, error) {
+	src := newRecordSource(in, opts)
+	rawHeader, err := src.Read()
+	if err == io.EOF {
+		in.Close()
+		return nil, fmt.Errorf("CSV %q has no rows", name)
+	}
+	if err != nil {
+		in.Close()
+		return nil, fmt.Errorf("error reading CSV: %w%s", err, lenientHint(opts))
+	}
+
+	if hasExportNotes(rawHeader) {
+		logging.Infof("newModelFromCSV: %s is an export, reading its Mark and Comment columns back as annotations", name)
+		src, rawHeader = newExportNotesSource(src, rawHeader)
 	}
 
-	m := initialModelFromCSV(records)
-	m.InitialPath = path
+	m := initialModelFromHeader(rawHeader)
+	m.InitialPath = name
+	if err := m.startSource(in, src, tail, opts); err != nil {
+		return nil, fmt.Errorf("error reading CSV: %w%s", err, lenientHint(opts))
+	}
 	m.InitialiseUI()
 	return m, nil
 }
 
-// Builds rows from CSV
-func initialModelFromCSV(data [][]string) *model {
-	rawHeader := data[0]
-	cols := make([]ColumnMeta, len(rawHeader))
-	for i, name := range rawHeader {
-		role := detectRole(name)
-		cols[i] = ColumnMeta{
-			Name:     name,
-			Index:    i,
-			Role:     role,
-			Visible:  true,
-			MinWidth: defaultMinWidthForRole(role),
-			Weight:   defaultWeightForRole(role),
-		}
-	}
+// newModelFromProfile loads a plain-text log, one row per line, with the
+// columns and timestamp format taken from the parser profile.
+func newModelFromProfile(in *inputFile, name string, tail *tailReader, p *parserProfile, opts loadOptions) (*model, error) {
+	logging.Infof("newModelFromProfile: reading %s with the %s profile", name, p.Name)
+	src := newProfileSource(in.Reader, p, opts.skipBad)
+	rawHeader, _ := src.Read()
 
-	rows := make([]renderedRow, 0, len(data)-1)
+	m := initialModelFromHeader(rawHeader)
+	m.InitialPath = name
+	m.data.profile = p.Name
+	m.data.timeLayout = resolveTimeLayout(p.TimeLayout)
+	m.data.idColumns = identityColumns(opts, p)
+	if err := m.startSource(in, src, tail, opts); err != nil {
+		return nil, fmt.Errorf("error reading %s: %w", name, err)
+	}
+	m.InitialiseUI()
+	return m, nil
+}
 
-	hasData := make([]bool, len(cols)) // track which columns ever have content
+// startSource wraps src in a row stream, following tail if set.
+func (m *model) startSource(in *inputFile, src recordSource, tail *tailReader, opts loadOptions) error {
+	m.data.timeRef = in.modTime
+	m.data.sourceZone, m.data.sourceZoneName = opts.tz, opts.tzName
+	if in.path != "" && tail == nil {
+		m.data.source = &sourceFile{path: in.path, size: in.size, modTime: in.modTime, opts: opts}
+	}
+	if opts.timeLayout != "" {
+		m.data.timeLayout = opts.timeLayout
+	}
+	if m.data.idColumns == nil {
+		m.data.idColumns = identityColumns(opts, nil)
+	}
+	stream := newRowStream(src, in.counter, in.size, in)
+	stream.ids = newRowIdentity(m.idColumnIndices())
+	if tail != nil {
+		stream.follow(tail)
+	}
+	return m.startStream(stream)
+}
 
-	for i, csvRow := range data[1:] {
-		// Track whether this row has data in each column
-		for colIdx := range cols {
-			if colIdx < len(csvRow) && strings.TrimSpace(csvRow[colIdx]) != "" {
-				hasData[colIdx] = true
-			}
+// startStream reads the first page synchronously and leaves the rest of the
+// stream to be picked up by Init.
+func (m *model) startStream(stream *rowStream) error {
+	rows, err := stream.readBatch(loadFirstBatchSize)
+	if err != nil && err != io.EOF {
+		if stream.closer != nil {
+			stream.closer.Close()
 		}
+		return err
+	}
 
-		// Build your row like normal
-		row := renderedRow{
-			cols:   csvRow,
-			height: 1,
-		}
-		row.id = row.ComputeID()
-		row.originalIndex = i + 1
-
-		rows = append(rows, row)
-	}
-
-	// After building rows: mark empty columns
-	for i := range cols {
-		if !hasData[i] {
-			// Entire column was empty -> hide it (unless it's your primary/details column)
-			if cols[i].Role != RolePrimary {
-				logging.Warnf("No column data in none-Primary colum: %d so setting visibility to false", i)
-				cols[i].Visible = false
-			}
-			cols[i].Weight = 0
-			cols[i].Width = 0
+	m.load = &loadState{stream: stream, read: stream.counter.Count()}
+	m.load.issues = stream.takeIssues()
+	m.applyRowNotes(stream.takeNotes())
+	m.addColumns(stream.takeColumns())
+	for i := range rows {
+		m.padRow(&rows[i])
+	}
+	m.data.rows = append(m.data.rows, rows...)
+	m.trackColumnData(rows)
+
+	if err == io.EOF && stream.tail == nil {
+		if stream.closer != nil {
+			stream.closer.Close()
 		}
+		logging.Infof("Loaded %d rows before start-up, nothing left to stream", len(rows))
+		return nil
+	}
+	m.load.active = true
+	go stream.run()
+	return nil
+}
+
+// newColumnMeta describes column i of a freshly read header.
+func newColumnMeta(i int, raw string) ColumnMeta {
+	name := normalizeHeaderName(raw)
+	role := detectRole(name)
+	return ColumnMeta{
+		Name:     name,
+		Index:    i,
+		Role:     role,
+		Visible:  true,
+		MinWidth: defaultMinWidthForRole(role),
+		Weight:   defaultWeightForRole(role),
+	}
+}
+
+// Builds the column metadata for a CSV header; rows are appended as they are read
+func initialModelFromHeader(rawHeader []string) *model {
+	cols := make([]ColumnMeta, len(rawHeader))
+	for i, raw := range rawHeader {
+		cols[i] = newColumnMeta(i, raw)
 	}
 
 	return &model{
 		data: dataState{
 			header:      cols,
-			rows:        rows,
 			markedRows:  make(map[uint64]MarkColor),
 			commentRows: make(map[uint64]string),
 		},
@@ -13,6 +13,19 @@ var logFile = flag.String("debug", "", "Write Debug Logs to file")
 
 func main() {
 	versionFlag := flag.Bool("version", false, "print version and exit")
+	followFlag := flag.Bool("follow", false, "keep watching the CSV and append rows as they are written")
+	delimiterFlag := flag.String("delimiter", "", "CSV field delimiter, e.g. ',', ';', '|' or 'tab' (default: detect)")
+	encodingFlag := flag.String("encoding", "", "input text encoding: utf-8, utf-16le, utf-16be, latin1, windows-1252 (default: detect)")
+	lenientFlag := flag.Bool("lenient", false, "load around ragged or badly quoted lines instead of failing; D shows what was repaired")
+	skipBadFlag := flag.Bool("skip-bad", false, "with --lenient, drop bad lines instead of keeping them flagged")
+	timeKeyFlag := flag.String("time-key", "", "JSON Lines key to take timestamps from (default: time, timestamp, @timestamp, ts, ...)")
+	metaFlag := flag.String("meta", "", "merge marks and comments from an annotations file saved with :savemeta")
+	idColsFlag := flag.String("id-cols", "", "columns that identify a row for marks and comments, e.g. Time,Host,Details (default: all)")
+	tzFlag := flag.String("tz", "", "zone of timestamps written without one: Europe/London, UTC, Local or an abbreviation like CET (default: UTC)")
+	timeLayoutFlag := flag.String("time-layout", "", "timestamp format for this file: a Go layout or hostlog, iso8601, syslog, epoch-ms, epoch (default: try each)")
+	snapshotRowsFlag := flag.String("snapshot-rows", "", "how saved snapshots keep rows: inline, or source to refer to the loaded file by path and SHA-256 (default: inline)")
+	autosaveFlag := flag.Duration("autosave", 0, "write the session to a hidden autosave file this often, e.g. 5m (default: off, or the config's)")
+	profileFlag := flag.String("profile", "", "parse a plain-text log with a named profile, e.g. syslog, rfc5424, applog (default: detect)")
 
 	flag.Parse()
 
@@ -31,20 +44,115 @@ func main() {
 
 	logging.Info("siftly-hostlog: Started")
 
+	delimiter, err := parseDelimiter(*delimiterFlag)
+	if err != nil {
+		fmt.Println("Error:", err)
+		os.Exit(1)
+	}
+	encoding, err := normalizeEncodingName(*encodingFlag)
+	if err != nil {
+		fmt.Println("Error:", err)
+		os.Exit(1)
+	}
+	cfg, err := loadConfig()
+	if err != nil {
+		fmt.Println("Error reading config:", err)
+		os.Exit(1)
+	}
+	profiles, err := loadProfiles(cfg)
+	if err != nil {
+		fmt.Println("Error in config:", err)
+		os.Exit(1)
+	}
+	if err := setTimeLayouts(cfg.TimeLayouts); err != nil {
+		fmt.Println("Error in config:", err)
+		os.Exit(1)
+	}
+	defaultIDColumns = cfg.IDColumns
+	rowsMode := cfg.SnapshotRows
+	if *snapshotRowsFlag != "" {
+		rowsMode = *snapshotRowsFlag
+	}
+	if snapshotRowsMode, err = parseSnapshotRows(rowsMode); err != nil {
+		fmt.Println("Error:", err)
+		os.Exit(1)
+	}
+	if autosaveInterval, err = parseAutosave(cfg.Autosave); err != nil {
+		fmt.Println("Error in config:", err)
+		os.Exit(1)
+	}
+	if *autosaveFlag > 0 {
+		autosaveInterval = *autosaveFlag
+	}
+	if cfg.Backups != nil {
+		snapshotBackups = *cfg.Backups
+	}
+	opts := loadOptions{
+		follow:     *followFlag,
+		delimiter:  delimiter,
+		encoding:   encoding,
+		lenient:    *lenientFlag || *skipBadFlag,
+		skipBad:    *skipBadFlag,
+		profiles:   profiles,
+		timeKey:    *timeKeyFlag,
+		timeLayout: resolveTimeLayout(*timeLayoutFlag),
+		idColumns:  parseColumnList(*idColsFlag),
+	}
+	if *tzFlag != "" {
+		if opts.tz, err = parseZone(*tzFlag); err != nil {
+			fmt.Println("Error:", err)
+			os.Exit(1)
+		}
+		opts.tzName = *tzFlag
+	}
+	if *profileFlag != "" {
+		if opts.profile, err = findProfile(profiles, *profileFlag); err != nil {
+			fmt.Println("Error:", err)
+			os.Exit(1)
+		}
+	}
+
 	args := flag.Args()
-	if len(args) < 1 {
-		fmt.Println("Usage: sfhost [--debug debug.log] <file.csv|file.json>")
+	var inputPath string
+	switch {
+	case len(args) >= 1:
+		inputPath = args[0]
+	case stdinIsPiped():
+		inputPath = stdinPath
+	default:
+		fmt.Println("Usage: sfhost [--debug debug.log] <file.csv|file.json|-> [more.csv ...]")
 		os.Exit(1)
 	}
 
-	inputPath := args[0]
+	var m *model
+	if len(args) > 1 {
+		if *followFlag {
+			logging.Fatalf("--follow works with a single file, got %d", len(args))
+		}
+		m, err = loadMergedModel(args, opts)
+		if err != nil {
+			logging.Fatalf("failed to merge %q: %v", args, err)
+		}
+	} else {
+		m, err = loadModelAuto(inputPath, opts)
+		if err != nil {
+			logging.Fatalf("failed to load %q: %v", inputPath, err)
+		}
+	}
 
-	m, err := loadModelAuto(inputPath)
-	if err != nil {
-		logging.Fatalf("failed to load %q: %v", inputPath, err)
+	m.checkAutosave(args)
+	// what --meta brings in is not on disk with the rows yet
+	m.markSaved()
+	if *metaFlag != "" {
+		m.startupNotice, m.startupNoticeKind = m.mergeMetaFile(*metaFlag)
 	}
 
-	_, err = tea.NewProgram(m, tea.WithAltScreen()).Run()
+	progOpts := []tea.ProgramOption{tea.WithAltScreen()}
+	if inputPath == stdinPath {
+		// stdin carries the data, so keys have to come from the terminal itself
+		progOpts = append(progOpts, tea.WithInputTTY())
+	}
+	_, err = tea.NewProgram(m, progOpts...).Run()
 	if err != nil {
 		logging.Errorf("Tea program error: %v", err)
 		fmt.Println("Error:", err)
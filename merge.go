@@ -0,0 +1,292 @@
+package main
+
+import (
+	"cmp"
+	"fmt"
+	"io"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/andareed/siftly-hostlog/logging"
+)
+
+// sourceColumnName is the synthetic column naming the file each merged row came from.
+const sourceColumnName = "Source"
+
+// mergeInput is one file's worth of rows before they are interleaved.
+type mergeInput struct {
+	source  string
+	header  []string
+	records [][]string
+	flagged []bool
+	notes   []rowNote // set for a re-opened export, one per record
+	issues  []loadIssue
+}
+
+type mergedRow struct {
+	cols    []string
+	ts      time.Time
+	seq     int64
+	hasTime bool
+	flagged bool
+	note    rowNote
+}
+
+// loadMergedModel reads several CSVs into one view. Headers are unioned by
+// column name, a Source column records where each row came from, and rows are
+// interleaved by timestamp. Every row is needed before it can be placed, so
+// unlike a single file this load is not streamed.
+func loadMergedModel(paths []string, opts loadOptions) (*model, error) {
+	if opts.profile != nil {
+		return nil, fmt.Errorf("--profile cannot be used when merging, only CSV files can be merged")
+	}
+	inputs := make([]mergeInput, 0, len(paths))
+	sources := sourceNames(paths)
+	for i, path := range paths {
+		in, err := readMergeInput(path, opts)
+		if err != nil {
+			return nil, err
+		}
+		in.source = sources[i]
+		inputs = append(inputs, in)
+	}
+
+	layouts := timeLayouts
+	if opts.timeLayout != "" {
+		layouts = []string{opts.timeLayout}
+	}
+	header, colMaps := unionHeaders(inputs)
+	timeIdx := detectMergeTimeColumn(inputs, header, colMaps, layouts, opts)
+
+	var merged []mergedRow
+	newestFirst := 0
+	for i, in := range inputs {
+		first := len(merged)
+		for k, rec := range in.records {
+			cols := mapMergeRecord(in, rec, colMaps[i], len(header))
+			row := mergedRow{cols: cols, flagged: in.flagged[k]}
+			if in.notes != nil {
+				row.note = in.notes[k]
+			}
+			if timeIdx >= 0 {
+				row.ts, row.seq, _, row.hasTime = parseTimestamp(cols[timeIdx], layouts, time.Time{}, opts.tz)
+			}
+			merged = append(merged, row)
+		}
+		if fileIsNewestFirst(merged[first:]) {
+			newestFirst++
+		}
+	}
+
+	// Host logs are usually exported newest first; keep that orientation if
+	// every input agrees, otherwise fall back to oldest first.
+	descending := newestFirst == len(inputs)
+	sort.SliceStable(merged, func(a, b int) bool {
+		ra, rb := merged[a], merged[b]
+		if ra.hasTime != rb.hasTime {
+			return ra.hasTime // rows without a timestamp sink to the bottom
+		}
+		if !ra.hasTime {
+			return false
+		}
+		// the host log sequence number orders rows within the same second
+		c := ra.ts.Compare(rb.ts)
+		if c == 0 {
+			c = cmp.Compare(ra.seq, rb.seq)
+		}
+		if descending {
+			return c > 0
+		}
+		return c < 0
+	})
+
+	m := initialModelFromHeader(header)
+	if timeIdx >= 0 {
+		m.data.timeColumn = header[timeIdx] // rows were ordered by it
+	}
+	m.data.timeLayout = opts.timeLayout
+	m.data.sourceZone, m.data.sourceZoneName = opts.tz, opts.tzName
+	m.data.header[0].Role = RoleSecondary
+	m.data.header[0].MinWidth = defaultMinWidthForRole(RoleSecondary)
+	m.data.header[0].Weight = defaultWeightForRole(RoleSecondary)
+
+	records := make([][]string, 0, len(merged)+1)
+	records = append(records, header)
+	m.data.rows = make([]renderedRow, 0, len(merged))
+	m.data.idColumns = withSourceColumn(identityColumns(opts, nil))
+	ids := newRowIdentity(m.idColumnIndices())
+	for i, mr := range merged {
+		row := renderedRow{
+			cols:          mr.cols,
+			height:        1,
+			originalIndex: i + 1,
+			flagged:       mr.flagged,
+		}
+		ids.assign(&row)
+		if !mr.note.empty() {
+			mr.note.id = row.id
+			m.applyRowNotes([]rowNote{mr.note})
+		}
+		m.data.rows = append(m.data.rows, row)
+		records = append(records, mr.cols)
+	}
+	markEmptyColumns(m.data.header, records)
+
+	// Issue line numbers are per file, so name the file alongside them
+	m.load = &loadState{}
+	for _, in := range inputs {
+		for _, issue := range in.issues {
+			issue.Row = 0
+			issue.Detail = in.source + ": " + issue.Detail
+			m.load.issues = append(m.load.issues, issue)
+		}
+	}
+
+	dir := filepath.Dir(paths[0])
+	m.InitialPath = filepath.Join(dir, "merged-"+filepath.Base(trimCompressionExt(paths[0])))
+	logging.Infof("Merged %d rows from %d files (newest first: %t)", len(m.data.rows), len(paths), descending)
+	m.InitialiseUI()
+	return m, nil
+}
+
+func readMergeInput(path string, opts loadOptions) (mergeInput, error) {
+	in, err := openInput(path)
+	if err != nil {
+		return mergeInput{}, fmt.Errorf("error opening file: %w", err)
+	}
+	defer in.Close()
+	if err := in.decode(opts.encoding); err != nil {
+		return mergeInput{}, fmt.Errorf("%s: %w", path, err)
+	}
+	if in.sniff() != formatCSV || pickProfile(in, opts) != nil {
+		return mergeInput{}, fmt.Errorf("%s: only CSV files can be merged", path)
+	}
+
+	src := newRecordSource(in, opts)
+	header, err := src.Read()
+	if err == io.EOF {
+		return mergeInput{}, fmt.Errorf("CSV %q has no rows", path)
+	}
+	if err != nil {
+		return mergeInput{}, fmt.Errorf("%s: error reading CSV: %w%s", path, err, lenientHint(opts))
+	}
+
+	exported := hasExportNotes(header)
+	if exported {
+		src, header = newExportNotesSource(src, header)
+	}
+	input := mergeInput{header: header}
+	for {
+		rec, err := src.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return mergeInput{}, fmt.Errorf("%s: error reading CSV: %w%s", path, err, lenientHint(opts))
+		}
+		input.records = append(input.records, rec)
+		input.flagged = append(input.flagged, sourceRepaired(src))
+		if exported {
+			input.notes = append(input.notes, sourceNote(src))
+		}
+	}
+	input.issues = sourceIssues(src)
+	return input, nil
+}
+
+// withSourceColumn adds Source to a merge's identity columns, so rows that are
+// otherwise alike but came from different files keep their own marks.
+func withSourceColumn(names []string) []string {
+	if len(names) == 0 {
+		return names
+	}
+	for _, name := range names {
+		if strings.EqualFold(name, sourceColumnName) {
+			return names
+		}
+	}
+	return append([]string{sourceColumnName}, names...)
+}
+
+// unionHeaders builds the merged header (Source first, then every column name
+// in order of first appearance) and, per input, where each of its columns lands.
+func unionHeaders(inputs []mergeInput) ([]string, [][]int) {
+	header := []string{sourceColumnName}
+	byName := map[string]int{strings.ToLower(sourceColumnName): 0}
+	colMaps := make([][]int, len(inputs))
+
+	for i, in := range inputs {
+		colMaps[i] = make([]int, len(in.header))
+		for j, name := range in.header {
+			key := strings.ToLower(normalizeHeaderName(name))
+			idx, ok := byName[key]
+			if !ok {
+				idx = len(header)
+				byName[key] = idx
+				header = append(header, name)
+			}
+			colMaps[i][j] = idx
+		}
+	}
+	return header, colMaps
+}
+
+// sourceNames labels each input by its base name, falling back to the full
+// path when two inputs share a base name.
+func sourceNames(paths []string) []string {
+	counts := make(map[string]int, len(paths))
+	for _, p := range paths {
+		counts[filepath.Base(p)]++
+	}
+	names := make([]string, len(paths))
+	for i, p := range paths {
+		names[i] = filepath.Base(p)
+		if counts[names[i]] > 1 {
+			names[i] = p
+		}
+	}
+	return names
+}
+
+func fileIsNewestFirst(rows []mergedRow) bool {
+	var first, last *mergedRow
+	for i := range rows {
+		if !rows[i].hasTime {
+			continue
+		}
+		if first == nil {
+			first = &rows[i]
+		}
+		last = &rows[i]
+	}
+	return first != nil && (first.ts.After(last.ts) || first.ts.Equal(last.ts) && first.seq > last.seq)
+}
+
+// mapMergeRecord lays a record out under the unioned header.
+func mapMergeRecord(in mergeInput, rec []string, colMap []int, width int) []string {
+	cols := make([]string, width)
+	cols[0] = in.source
+	for j, value := range rec {
+		if j < len(colMap) {
+			cols[colMap[j]] = value
+		}
+	}
+	return cols
+}
+
+// detectMergeTimeColumn looks for the time column across the first rows of
+// every input.
+func detectMergeTimeColumn(inputs []mergeInput, header []string, colMaps [][]int, layouts []string, opts loadOptions) int {
+	var sample [][]string
+	for i, in := range inputs {
+		for _, rec := range in.records[:min(len(in.records), typeSampleSize)] {
+			sample = append(sample, mapMergeRecord(in, rec, colMaps[i], len(header)))
+		}
+	}
+	return detectTimeColumn(header, sample, func(v string) bool {
+		_, _, _, ok := parseTimestamp(v, layouts, time.Time{}, opts.tz)
+		return ok
+	})
+}
@@ -41,11 +41,25 @@ type model struct {
 	fileName            string // filename the data will be saved to
 	InitialPath         string
 	lastExportFileName  string
+	exportOptions       dialogs.ExportOptions // choices made in the last export dialog
 	ui                  uiState
 	data                dataState
+	load                *loadState // nil unless rows are being streamed in
+	pendingMeta         *metaFile  // annotations still being matched against rows streaming in
+	startupNotice       string     // shown when the UI starts, e.g. the --meta summary
+	startupNoticeKind   string
+	autosavePath        string         // where the session autosaves, "" for stdin
+	recovery            *autosaveOffer // autosave found at startup, until answered
+	savedState          uint64         // sessionFingerprint when last loaded or saved
+	autosavedState      uint64         // sessionFingerprint when last autosaved
+	autosaving          bool           // an autosave is being written in the background
+	isDirty             bool           // sessionFingerprint differs from savedState, while dirtyKnown
+	dirtyKnown          bool           // isDirty is current; cleared by touchSession
+	history             editHistory    // marks, comments, filter and time window edits for undo
 }
 
 func (m *model) InitialiseUI() {
+	m.touchSession() // a load or recovery has replaced the session
 	m.data.showOnlyMarked = false
 	m.drawerPort = viewport.New(0, 0)
 	m.ui.drawerHeight = 13 // TODO:should be a better way of calcing this rather than hardcoding
@@ -57,6 +71,12 @@ func (m *model) InitialiseUI() {
 		focus:      timeWindowFocusStart,
 	}
 	m.computeTimeBounds()
+	m.inferColumnTypes(m.data.rows)
+	// a filter restored from a snapshot needs the column types
+	if err := m.compileFilter(m.data.filterPattern); err != nil {
+		logging.Warnf("InitialiseUI: dropping filter %q: %v", m.data.filterPattern, err)
+		m.compileFilter("")
+	}
 	if m.data.timeWindow.Enabled && m.data.hasTimeBounds {
 		m.data.timeWindow.Start = clampTimeToBounds(m.data.timeWindow.Start, m.data.timeMin, m.data.timeMax)
 		m.data.timeWindow.End = clampTimeToBounds(m.data.timeWindow.End, m.data.timeMin, m.data.timeMax)
@@ -72,6 +92,26 @@ func (m *model) InitialiseUI() {
 func (m *model) Init() tea.Cmd {
 	m.applyFilter()
 	logging.Info("siftly-hostlog: Initialised")
+	if m.recovery != nil {
+		m.offerRecovery()
+	}
+	return tea.Batch(m.startupCmd(), m.scheduleAutosave())
+}
+
+// startupCmd picks up a streaming load or shows what happened while loading.
+func (m *model) startupCmd() tea.Cmd {
+	if m.load != nil && m.load.active {
+		return m.load.stream.waitForBatch()
+	}
+	if m.load != nil && len(m.load.issues) > 0 {
+		return m.startNotice(fmt.Sprintf("%d line(s) repaired or skipped while loading (D for details)", len(m.load.issues)), "warn", noticeDuration)
+	}
+	if m.startupNotice != "" {
+		return m.startNotice(m.startupNotice, m.startupNoticeKind, noticeDuration)
+	}
+	if m.data.unmatchedNotes > 0 {
+		return m.startNotice(fmt.Sprintf("%d annotated row(s) in the snapshot match no row", m.data.unmatchedNotes), "warn", noticeDuration)
+	}
 	return nil
 }
 
@@ -88,6 +128,9 @@ func (m *model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	if cmd, handled := m.handleSystemMsg(msg); handled {
 		return m, cmd
 	}
+	if cmd, handled := m.handleLoadMsg(msg); handled {
+		return m, cmd
+	}
 	if cmd, handled := m.handleDialogInput(msg); handled {
 		return m, cmd
 	}
@@ -115,6 +158,10 @@ func (m *model) handleSystemMsg(msg tea.Msg) (tea.Cmd, bool) {
 			m.ui.noticeType = ""
 		}
 		return nil, true
+	case autosaveTickMsg:
+		return m.autosave(), true
+	case autosaveDoneMsg:
+		return m.autosaveDone(msg), true
 	}
 	return nil, false
 }
@@ -154,8 +201,19 @@ func (m *model) handleDialogMsg(msg tea.Msg) (tea.Cmd, bool) {
 		m.activeDialog = dialogs.NewHelpDialog(Keys.Legend())
 		m.activeDialog.Show()
 		return nil, true
+	case dialogs.DiagnosticsRequestedMsg:
+		if m.load == nil || len(m.load.issues) == 0 {
+			return m.startNotice("No load issues", "", noticeDuration), true
+		}
+		summary, lines := m.loadDiagnostics()
+		m.activeDialog = dialogs.NewDiagnosticsDialog(summary, lines)
+		m.activeDialog.Show()
+		return nil, true
 	case dialogs.SaveRequestedMsg:
 		logging.Infof("Update was called with msg SaveRequestedMsg (should pop a dialog box)")
+		if m.load.busy() {
+			return m.startNotice("Still loading rows, save once the load completes", "warn", noticeDuration), true
+		}
 		m.activeDialog = dialogs.NewSaveDialog(defaultSaveName(*m), filepath.Dir(m.fileName))
 		m.activeDialog.Show()
 		return nil, true
@@ -166,6 +224,8 @@ func (m *model) handleDialogMsg(msg tea.Msg) (tea.Cmd, bool) {
 			return m.startNotice("Error", "", noticeDuration), true
 		}
 		m.fileName = msg.Path
+		m.markSaved()
+		m.clearAutosave()
 		return m.startNotice("Saved succeeded", "", noticeDuration), true
 	case dialogs.SaveCanceledMsg:
 		logging.Debugf("model:Update::Received SaveCanceledMsg from dialog and hiding the active dialog")
@@ -173,15 +233,17 @@ func (m *model) handleDialogMsg(msg tea.Msg) (tea.Cmd, bool) {
 		return nil, true
 	case dialogs.ExportRequestedMsg:
 		logging.Infof("Update wwas called with msg ExportRequestMsg (pop dialog for exports)")
-		m.activeDialog = dialogs.NewExportDialog(defaultExportName(*m), filepath.Dir(m.fileName))
+		m.activeDialog = dialogs.NewExportDialog(defaultExportName(*m), filepath.Dir(m.fileName), m.exportOptions)
 		// TODO: What filename should this default to for an export?
 		m.activeDialog.Show()
 		return nil, true
 	case dialogs.ExportConfirmedMsg:
 		logging.Infof("module:Update::ExportConfirmedMsg begin exporting to the file")
 		m.activeDialog.Hide()
-		if err := ExportModel(m, msg.Path); err != nil {
-			return m.startNotice("Export Error", "", noticeDuration), true
+		m.exportOptions = msg.Options
+		if err := ExportModel(m, msg.Path, msg.Options); err != nil {
+			logging.Errorf("export: %v", err)
+			return m.startNotice("Export failed: "+err.Error(), "error", noticeDuration), true
 		}
 		m.lastExportFileName = msg.Path
 		return m.startNotice("Exported succeeded", "", noticeDuration), true
@@ -189,6 +251,19 @@ func (m *model) handleDialogMsg(msg tea.Msg) (tea.Cmd, bool) {
 		logging.Debugf("model:Update:: Received ExportCanceledMsg, close down the dialog")
 		m.activeDialog.Hide()
 		return nil, true
+	case dialogs.ConfirmChoiceMsg:
+		m.activeDialog.Hide()
+		switch msg.ID {
+		case confirmRecover:
+			if msg.Key == "y" {
+				return m.recoverAutosave(), true
+			}
+			logging.Infof("model:Update:: autosave %s not recovered", m.recovery.path)
+			m.recovery = nil
+		case confirmQuit:
+			return m.answerQuit(msg.Key), true
+		}
+		return nil, true
 	}
 	return nil, false
 }
@@ -276,7 +351,7 @@ func (m *model) handleViewModeKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 		cmd = m.enterCommand(CmdComment, "", true, false)
 	//TODO: Implement Serach
 	case key.Matches(msg, Keys.Quit):
-		return m, tea.Quit
+		return m, m.quit()
 	case key.Matches(msg, Keys.CopyRow):
 		logging.Infof("Key Combination for CopyRow To Clipboard")
 		cmd = m.copyRowToClipboard()
@@ -290,7 +365,7 @@ func (m *model) handleViewModeKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 		// Show Marks only
 		logging.Infof("Toggle for Show Marks Only has been pressed")
 		m.data.showOnlyMarked = !m.data.showOnlyMarked
-		cmd = m.startNotice(fmt.Sprintf("'Show Only Marked Rows' toggled {%b}", m.data.showOnlyMarked), "", noticeDuration)
+		cmd = m.startNotice(fmt.Sprintf("'Show Only Marked Rows' toggled {%t}", m.data.showOnlyMarked), "", noticeDuration)
 		m.applyFilter()
 	case key.Matches(msg, Keys.NextMark):
 		// Next mark jump
@@ -308,6 +383,10 @@ func (m *model) handleViewModeKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 			cmd = m.startNotice("No matches", "warn", noticeDuration)
 		}
 		m.ready = true
+	case key.Matches(msg, Keys.Undo):
+		cmd = m.undo()
+	case key.Matches(msg, Keys.Redo):
+		cmd = m.redo()
 	case key.Matches(msg, Keys.ClearFilter):
 		// Clear Filter
 		logging.Infof("Shift F, clearing Filter")
@@ -340,6 +419,16 @@ func (m *model) handleViewModeKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 		m.pageDown()
 	case key.Matches(msg, Keys.OpenHelp):
 		return m, func() tea.Msg { return dialogs.HelpRequestedMsg{} }
+	case key.Matches(msg, Keys.Diagnostics):
+		return m, func() tea.Msg { return dialogs.DiagnosticsRequestedMsg{} }
+	case key.Matches(msg, Keys.DisplayZone):
+		return m, m.cycleDisplayZone()
+	case key.Matches(msg, Keys.ColumnLeft):
+		m.moveColumnCursor(-1)
+	case key.Matches(msg, Keys.ColumnRight):
+		m.moveColumnCursor(1)
+	case key.Matches(msg, Keys.Sort):
+		return m, m.cycleSort()
 	case key.Matches(msg, Keys.ScrollLeft):
 		m.viewport.ScrollLeft(4) // tune step
 	case key.Matches(msg, Keys.ScrollRight):
@@ -454,7 +543,7 @@ func (m *model) applyFilter() {
 	currentRowHash := m.currentRowHashID()              // should be called before we reset the filteredIndices
 	m.data.filteredIndices = m.data.filteredIndices[:0] // reset slice
 
-	if m.data.filterRegex == nil && !m.data.showOnlyMarked && !m.data.timeWindow.Enabled {
+	if m.data.filterRegex == nil && len(m.data.filterPreds) == 0 && !m.data.showOnlyMarked && !m.data.timeWindow.Enabled {
 		logging.Debug("applyFilter: No filter text and showOnly marked is false there all indices being added to filteredIncidices")
 		// Maybe used clamp?
 		for i := range m.data.rows {
@@ -463,6 +552,7 @@ func (m *model) applyFilter() {
 		if len(m.data.filteredIndices) == 0 {
 			m.cursor = 0
 		}
+		m.sortFiltered()
 		m.jumpToHashID(currentRowHash)
 		return
 	}
@@ -478,10 +568,31 @@ func (m *model) applyFilter() {
 		m.cursor = -1
 	}
 
+	m.sortFiltered()
 	m.jumpToHashID(currentRowHash)
 	m.clampCursor()
 }
 
+// extendFilter appends rows from start onwards that pass the current filter,
+// leaving the cursor where it is. With a sort in place the new rows are
+// sorted in and the cursor follows the selected row.
+func (m *model) extendFilter(start int) {
+	currentRowHash := m.currentRowHashID()
+	n := len(m.data.filteredIndices)
+	for i := start; i < len(m.data.rows); i++ {
+		if m.includeRow(m.data.rows[i], i) {
+			m.data.filteredIndices = append(m.data.filteredIndices, i)
+		}
+	}
+	if m.sortColumnIndex() >= 0 && len(m.data.filteredIndices) > n {
+		m.mergeFiltered(n)
+		if currentRowHash != 0 {
+			m.jumpToHashID(currentRowHash)
+		}
+	}
+	m.clampCursor()
+}
+
 // endregion
 
 func defaultExportName(m model) string {
@@ -489,7 +600,8 @@ func defaultExportName(m model) string {
 		return m.lastExportFileName
 	}
 
-	base := strings.TrimSuffix(m.InitialPath, filepath.Ext(m.InitialPath))
+	initial := trimCompressionExt(m.InitialPath)
+	base := strings.TrimSuffix(initial, filepath.Ext(initial))
 	return "export-" + base + ".csv"
 
 }
@@ -506,12 +618,13 @@ func defaultSaveName(m model) string {
 		return "output.json" // final fallback
 	}
 
-	// Case 3: if the initial path already ends with .json, use it
-	if strings.HasSuffix(strings.ToLower(initial), ".json") {
+	// Case 3: if the initial path already ends with .json (compressed or not), use it
+	if strings.HasSuffix(strings.ToLower(trimCompressionExt(initial)), ".json") {
 		return initial
 	}
 
 	// Case 4: replace any existing extension with .json
+	initial = trimCompressionExt(initial)
 	base := strings.TrimSuffix(initial, filepath.Ext(initial))
 	return base + ".json"
 }
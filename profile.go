@@ -0,0 +1,221 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// parserProfile turns plain-text log lines into columns: every named capture
+// group in Pattern becomes a column, in the order it appears. A group named
+// Time is used as the timestamp and parsed with TimeLayout.
+type parserProfile struct {
+	Name       string   `json:"name"`
+	Pattern    string   `json:"pattern"`
+	TimeLayout string   `json:"timeLayout"`
+	IDColumns  []string `json:"idColumns,omitempty"` // row identity, see identityColumns
+
+	re     *regexp.Regexp
+	header []string
+	groups []int // submatch index for each header column
+}
+
+// builtinProfiles cover the text logs the appliances write. Profiles in the
+// user config file with the same name take precedence.
+var builtinProfiles = []parserProfile{
+	{
+		// RFC 3164, optionally with the <PRI> prefix left on
+		Name:       "syslog",
+		Pattern:    `^(?:<\d{1,3}>)?(?P<Time>[A-Z][a-z]{2} [ \d]\d \d{2}:\d{2}:\d{2}) (?P<Host>\S+) (?P<Program>[^\s:\[]+)(?:\[(?P<PID>\d+)\])?: ?(?P<Details>.*)$`,
+		TimeLayout: time.Stamp,
+	},
+	{
+		Name:       "rfc5424",
+		Pattern:    `^<\d{1,3}>1 (?P<Time>\S+) (?P<Host>\S+) (?P<Program>\S+) (?P<PID>\S+) (?P<MsgID>\S+) (?:-|\[.*?\]) ?(?P<Details>.*)$`,
+		TimeLayout: time.RFC3339Nano,
+	},
+	{
+		// "2024-05-01 13:45:12,345 [ERROR] something broke" and close relatives
+		Name:       "applog",
+		Pattern:    `^(?P<Time>\d{4}-\d{2}-\d{2} \d{2}:\d{2}:\d{2}(?:[.,]\d+)?)\s+\[?(?P<Level>TRACE|DEBUG|INFO|NOTICE|WARN|WARNING|ERROR|CRIT|CRITICAL|FATAL)\]?:?\s+(?P<Details>.*)$`,
+		TimeLayout: "2006-01-02 15:04:05",
+	},
+}
+
+// compile checks the pattern and works out the columns it produces.
+func (p *parserProfile) compile() error {
+	re, err := regexp.Compile(p.Pattern)
+	if err != nil {
+		return fmt.Errorf("profile %q: %w", p.Name, err)
+	}
+	p.re = re
+	p.header, p.groups = nil, nil
+	seen := map[string]bool{}
+	for i, name := range re.SubexpNames() {
+		if name == "" || seen[name] {
+			continue
+		}
+		seen[name] = true
+		p.header = append(p.header, name)
+		p.groups = append(p.groups, i)
+	}
+	if len(p.header) == 0 {
+		return fmt.Errorf("profile %q: pattern has no named groups", p.Name)
+	}
+	return nil
+}
+
+// match splits a line into columns, or returns nil if the line does not fit.
+func (p *parserProfile) match(line string) []string {
+	sub := p.re.FindStringSubmatch(line)
+	if sub == nil {
+		return nil
+	}
+	cols := make([]string, len(p.groups))
+	for i, g := range p.groups {
+		cols[i] = sub[g]
+	}
+	return cols
+}
+
+// loadProfiles returns the user's profiles followed by the built-in ones,
+// all compiled. Earlier entries win when names clash.
+func loadProfiles(cfg appConfig) ([]*parserProfile, error) {
+	var profiles []*parserProfile
+	seen := map[string]bool{}
+	all := append(append([]parserProfile(nil), cfg.Profiles...), builtinProfiles...)
+	for i := range all {
+		p := &all[i]
+		key := strings.ToLower(p.Name)
+		if p.Name == "" || seen[key] {
+			continue
+		}
+		seen[key] = true
+		if err := p.compile(); err != nil {
+			return nil, err
+		}
+		profiles = append(profiles, p)
+	}
+	return profiles, nil
+}
+
+// findProfile looks a profile up by name, case-insensitively.
+func findProfile(profiles []*parserProfile, name string) (*parserProfile, error) {
+	names := make([]string, 0, len(profiles))
+	for _, p := range profiles {
+		if strings.EqualFold(p.Name, name) {
+			return p, nil
+		}
+		names = append(names, p.Name)
+	}
+	return nil, fmt.Errorf("unknown profile %q (have %s)", name, strings.Join(names, ", "))
+}
+
+// detectProfile returns the first profile that matches the opening line and
+// at least four in five of the lines in the sniff window, or nil.
+func detectProfile(head []byte, profiles []*parserProfile) *parserProfile {
+	var lines []string
+	all := strings.Split(string(trimPartialRune(head)), "\n")
+	if len(all) > 1 {
+		all = all[:len(all)-1] // the last line may be cut short by the sniff window
+	}
+	for _, line := range all {
+		line = strings.TrimRight(line, "\r")
+		if strings.TrimSpace(line) != "" {
+			lines = append(lines, line)
+		}
+		if len(lines) == 20 {
+			break
+		}
+	}
+	if len(lines) == 0 {
+		return nil
+	}
+
+	for _, p := range profiles {
+		if p.match(lines[0]) == nil {
+			continue
+		}
+		hits := 0
+		for _, line := range lines {
+			if p.match(line) != nil {
+				hits++
+			}
+		}
+		if hits*5 >= len(lines)*4 {
+			return p
+		}
+	}
+	return nil
+}
+
+// pickProfile returns the profile asked for on the command line or, failing
+// that, one recognised from the content. nil means the input is not plain text.
+func pickProfile(in *inputFile, opts loadOptions) *parserProfile {
+	if opts.profile != nil {
+		return opts.profile
+	}
+	head, _ := in.Peek(sniffSize)
+	return detectProfile(head, opts.profiles)
+}
+
+// profileSource reads plain-text lines through a parser profile. The first
+// record it returns is the header built from the profile's groups. Lines the
+// pattern does not match are kept whole in the last column and flagged, or
+// dropped with skipBad, and reported as load issues either way.
+type profileSource struct {
+	r          *bufio.Reader
+	profile    *parserProfile
+	skipBad    bool
+	headerSent bool
+	line       int
+	records    int
+	repaired   bool
+	issues     []loadIssue
+}
+
+func newProfileSource(r *bufio.Reader, p *parserProfile, skipBad bool) *profileSource {
+	return &profileSource{r: r, profile: p, skipBad: skipBad}
+}
+
+func (s *profileSource) lastRepaired() bool { return s.repaired }
+
+func (s *profileSource) takeIssues() []loadIssue {
+	issues := s.issues
+	s.issues = nil
+	return issues
+}
+
+func (s *profileSource) Read() ([]string, error) {
+	if !s.headerSent {
+		s.headerSent = true
+		return append([]string(nil), s.profile.header...), nil
+	}
+	for {
+		text, err := readLine(s.r)
+		if err != nil {
+			return nil, err
+		}
+		s.line++
+		if strings.TrimSpace(text) == "" {
+			continue
+		}
+
+		rec := s.profile.match(text)
+		s.repaired = rec == nil
+		if s.repaired {
+			detail := fmt.Sprintf("does not match the %s profile", s.profile.Name)
+			if s.skipBad {
+				s.issues = append(s.issues, loadIssue{Line: s.line, Skipped: true, Detail: detail})
+				continue
+			}
+			rec = make([]string, len(s.profile.header))
+			rec[len(rec)-1] = text
+			s.issues = append(s.issues, loadIssue{Line: s.line, Row: s.records + 1, Detail: detail + ", kept in " + s.profile.header[len(rec)-1]})
+		}
+		s.records++
+		return rec, nil
+	}
+}
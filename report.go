@@ -0,0 +1,320 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"html/template"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/andareed/siftly-hostlog/logging"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// reportColors is the order the report lists mark colours in, most urgent first.
+var reportColors = []MarkColor{MarkRed, MarkAmber, MarkGreen}
+
+// reportRow is one marked row as the report shows it.
+type reportRow struct {
+	Line    int
+	Time    string // parsed timestamp in the display zone, "" if it has none
+	Cells   []string
+	Comment string
+
+	ts      time.Time
+	hasTime bool
+}
+
+type reportGroup struct {
+	Color MarkColor
+	Rows  []reportRow
+}
+
+// report is a handover summary of the marked rows: what was marked, in time
+// order per colour, and the view it was marked in.
+type report struct {
+	Title     string
+	Generated string
+	Zone      string
+	Columns   []string // visible columns, in display order
+	Groups    []reportGroup
+	Start     string // time span of the marked rows, "" when none have a time
+	End       string
+	Duration  string
+	Filter    string
+	Window    string
+	Total     int // rows loaded
+	Shown     int // rows passing the filter and time window
+	Marked    int
+	Counts    string // marks per colour, e.g. "2 red, 1 green"
+	Commented int    // marked rows with a comment
+}
+
+// defaultReportName is where :report writes without a path, e.g.
+// "hostlog.report.md" for "hostlog.csv".
+func defaultReportName(m model) string {
+	initial := trimCompressionExt(m.InitialPath)
+	if initial == "" || initial == stdinName {
+		return "handover.report.md"
+	}
+	return strings.TrimSuffix(initial, filepath.Ext(initial)) + ".report.md"
+}
+
+// buildReport gathers the marked rows and the state of the view.
+func (m *model) buildReport() report {
+	loc := m.displayLocation()
+	r := report{
+		Title:     "Handover",
+		Generated: time.Now().In(loc).Format(timeInputLayout),
+		Zone:      m.displayZoneLabel(),
+		Filter:    m.data.filterPattern,
+		Total:     len(m.data.rows),
+		Shown:     len(m.data.filteredIndices),
+	}
+	if initial := trimCompressionExt(m.InitialPath); initial != "" && initial != stdinName {
+		r.Title = "Handover: " + filepath.Base(initial)
+	}
+	if w := m.data.timeWindow; w.Enabled {
+		r.Window = m.formatDisplayTime(w.Start) + " – " + m.formatDisplayTime(w.End)
+	}
+
+	var cols []int
+	for i, col := range m.data.header {
+		if col.Visible {
+			cols = append(cols, i)
+			r.Columns = append(r.Columns, col.Name)
+		}
+	}
+
+	byColor := make(map[MarkColor][]reportRow, len(reportColors))
+	var first, last time.Time
+	for i, row := range m.data.rows {
+		c, ok := m.data.markedRows[row.id]
+		if !ok {
+			continue
+		}
+		rr := reportRow{Line: row.originalIndex, Comment: m.data.commentRows[row.id]}
+		for _, ci := range cols {
+			v := ""
+			if ci < len(row.cols) {
+				v = row.cols[ci]
+			}
+			rr.Cells = append(rr.Cells, v)
+		}
+		if i < len(m.data.rowHasTimes) && m.data.rowHasTimes[i] {
+			rr.ts, rr.hasTime = m.data.rowTimes[i], true
+			rr.Time = m.formatDisplayTime(rr.ts)
+			if first.IsZero() || rr.ts.Before(first) {
+				first = rr.ts
+			}
+			if rr.ts.After(last) {
+				last = rr.ts
+			}
+		}
+		byColor[c] = append(byColor[c], rr)
+		r.Marked++
+		if rr.Comment != "" {
+			r.Commented++
+		}
+	}
+	if !first.IsZero() {
+		r.Start, r.End = m.formatDisplayTime(first), m.formatDisplayTime(last)
+		r.Duration = last.Sub(first).Round(time.Second).String()
+	}
+
+	var counts []string
+	for _, c := range reportColors {
+		rows := byColor[c]
+		if len(rows) == 0 {
+			continue
+		}
+		// time order; rows without a time follow in line order
+		sort.SliceStable(rows, func(a, b int) bool {
+			ra, rb := rows[a], rows[b]
+			if ra.hasTime != rb.hasTime {
+				return ra.hasTime
+			}
+			if ra.hasTime && !ra.ts.Equal(rb.ts) {
+				return ra.ts.Before(rb.ts)
+			}
+			return ra.Line < rb.Line
+		})
+		r.Groups = append(r.Groups, reportGroup{Color: c, Rows: rows})
+		counts = append(counts, fmt.Sprintf("%d %s", len(rows), c))
+	}
+	r.Counts = orNone(strings.Join(counts, ", "))
+	return r
+}
+
+// hasTimes reports whether any marked row has a timestamp, so the Time
+// column is only shown when it says something.
+func (r report) hasTimes() bool {
+	return r.Start != ""
+}
+
+// --- Markdown ---
+
+// mdCell keeps a value inside its table cell.
+func mdCell(s string) string {
+	s = strings.ReplaceAll(s, `\`, `\\`)
+	s = strings.ReplaceAll(s, "|", `\|`)
+	s = strings.ReplaceAll(s, "<", "&lt;")
+	s = strings.ReplaceAll(s, "\r\n", "<br>")
+	return strings.ReplaceAll(s, "\n", "<br>")
+}
+
+func (r report) markdown() []byte {
+	var b bytes.Buffer
+	fmt.Fprintf(&b, "# %s\n\n", r.Title)
+	fmt.Fprintf(&b, "Generated %s, times in %s.\n\n", r.Generated, r.Zone)
+
+	fmt.Fprintf(&b, "| Summary | |\n|---|---|\n")
+	fmt.Fprintf(&b, "| Marked rows | %d (%s) |\n", r.Marked, r.Counts)
+	fmt.Fprintf(&b, "| Comments | %d |\n", r.Commented)
+	fmt.Fprintf(&b, "| Rows shown | %d of %d |\n", r.Shown, r.Total)
+	if r.hasTimes() {
+		fmt.Fprintf(&b, "| Time span | %s – %s (%s) |\n", r.Start, r.End, r.Duration)
+	}
+	fmt.Fprintf(&b, "| Filter | %s |\n", mdCode(r.Filter))
+	fmt.Fprintf(&b, "| Time window | %s |\n", orNone(r.Window))
+
+	for _, g := range r.Groups {
+		fmt.Fprintf(&b, "\n## %s (%d)\n\n", strings.ToUpper(string(g.Color[:1]))+string(g.Color[1:]), len(g.Rows))
+		head := []string{"Line"}
+		if r.hasTimes() {
+			head = append(head, "Time")
+		}
+		head = append(head, r.Columns...)
+		head = append(head, "Comment")
+		for i := range head {
+			head[i] = mdCell(head[i])
+		}
+		fmt.Fprintf(&b, "| %s |\n", strings.Join(head, " | "))
+		fmt.Fprintf(&b, "|%s\n", strings.Repeat("---|", len(head)))
+		for _, row := range g.Rows {
+			cells := []string{fmt.Sprint(row.Line)}
+			if r.hasTimes() {
+				cells = append(cells, row.Time)
+			}
+			cells = append(cells, row.Cells...)
+			cells = append(cells, row.Comment)
+			for i := range cells {
+				cells[i] = mdCell(cells[i])
+			}
+			fmt.Fprintf(&b, "| %s |\n", strings.Join(cells, " | "))
+		}
+	}
+	if len(r.Groups) == 0 {
+		b.WriteString("\nNo rows are marked.\n")
+	}
+	return b.Bytes()
+}
+
+func mdCode(s string) string {
+	if s == "" {
+		return "none"
+	}
+	return "`" + strings.ReplaceAll(mdCell(s), "`", "'") + "`"
+}
+
+func orNone(s string) string {
+	if s == "" {
+		return "none"
+	}
+	return s
+}
+
+// --- HTML ---
+
+// reportHTML is a single page with its styles inline, so it can be mailed
+// as an attachment and opened anywhere.
+var reportHTML = template.Must(template.New("report").Funcs(template.FuncMap{
+	"title": func(c MarkColor) string { return strings.ToUpper(string(c[:1])) + string(c[1:]) },
+}).Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<title>{{.Title}}</title>
+<style>
+body { font-family: -apple-system, "Segoe UI", Helvetica, Arial, sans-serif; font-size: 14px; color: #222; margin: 2em; }
+h1 { font-size: 1.5em; margin-bottom: 0.2em; }
+h2 { font-size: 1.15em; margin-top: 1.8em; padding-left: 0.5em; border-left: 6px solid; }
+.meta { color: #666; margin-top: 0; }
+table { border-collapse: collapse; margin-top: 0.6em; }
+th, td { border: 1px solid #ccc; padding: 4px 8px; text-align: left; vertical-align: top; }
+th { background: #f2f2f2; }
+td.num { text-align: right; color: #666; }
+td.comment { font-style: italic; }
+code { background: #f2f2f2; padding: 1px 4px; }
+.red { border-color: #d9534f; } tr.red td:first-child { border-left: 4px solid #d9534f; }
+.amber { border-color: #f0ad4e; } tr.amber td:first-child { border-left: 4px solid #f0ad4e; }
+.green { border-color: #5cb85c; } tr.green td:first-child { border-left: 4px solid #5cb85c; }
+</style>
+</head>
+<body>
+<h1>{{.Title}}</h1>
+<p class="meta">Generated {{.Generated}}, times in {{.Zone}}.</p>
+<table>
+<tr><th>Marked rows</th><td>{{.Marked}} ({{.Counts}})</td></tr>
+<tr><th>Comments</th><td>{{.Commented}}</td></tr>
+<tr><th>Rows shown</th><td>{{.Shown}} of {{.Total}}</td></tr>
+{{- if .Start}}
+<tr><th>Time span</th><td>{{.Start}} – {{.End}} ({{.Duration}})</td></tr>
+{{- end}}
+<tr><th>Filter</th><td>{{if .Filter}}<code>{{.Filter}}</code>{{else}}none{{end}}</td></tr>
+<tr><th>Time window</th><td>{{if .Window}}{{.Window}}{{else}}none{{end}}</td></tr>
+</table>
+{{- $times := .Start}}
+{{- $columns := .Columns}}
+{{- range .Groups}}
+<h2 class="{{.Color}}">{{title .Color}} ({{len .Rows}})</h2>
+<table>
+<tr><th>Line</th>{{if $times}}<th>Time</th>{{end}}{{range $columns}}<th>{{.}}</th>{{end}}<th>Comment</th></tr>
+{{- $color := .Color}}
+{{- range .Rows}}
+<tr class="{{$color}}"><td class="num">{{.Line}}</td>{{if $times}}<td>{{.Time}}</td>{{end}}{{range .Cells}}<td>{{.}}</td>{{end}}<td class="comment">{{.Comment}}</td></tr>
+{{- end}}
+</table>
+{{- else}}
+<p>No rows are marked.</p>
+{{- end}}
+</body>
+</html>
+`))
+
+func (r report) html() ([]byte, error) {
+	var b bytes.Buffer
+	if err := reportHTML.Execute(&b, r); err != nil {
+		return nil, fmt.Errorf("render report: %w", err)
+	}
+	return b.Bytes(), nil
+}
+
+// WriteReport writes a handover report of the marked rows to path, as HTML
+// when the name ends in .html or .htm and as Markdown otherwise.
+func WriteReport(m *model, path string) error {
+	r := m.buildReport()
+	var data []byte
+	switch strings.ToLower(filepath.Ext(trimCompressionExt(path))) {
+	case ".html", ".htm":
+		var err error
+		if data, err = r.html(); err != nil {
+			return err
+		}
+	default:
+		data = r.markdown()
+	}
+	return writeOutputFile(path, data)
+}
+
+// writeReport runs :report.
+func (m *model) writeReport(path string) tea.Cmd {
+	if err := WriteReport(m, path); err != nil {
+		logging.Errorf("writeReport: %v", err)
+		return m.startNotice(fmt.Sprintf("Report failed: %v", err), "error", noticeDuration)
+	}
+	logging.Infof("writeReport: wrote %d marked row(s) to %s", len(m.data.markedRows), path)
+	return m.startNotice(fmt.Sprintf("Wrote a report of %d marked row(s) to %s", len(m.data.markedRows), path), "success", noticeDuration)
+}
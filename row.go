@@ -1,6 +1,7 @@
 package main
 
 import (
+	"encoding/binary"
 	"hash/fnv"
 	"strings"
 
@@ -11,20 +12,140 @@ type renderedRow struct {
 	cols          []string
 	height        int
 	id            uint64
-	originalIndex int // Essentially the row number of the source, not a unique ID
+	originalIndex int  // Essentially the row number of the source, not a unique ID
+	flagged       bool // repaired by the lenient loader, see loadIssue
 }
 
-// method on the struct
-func (r renderedRow) ComputeID() uint64 {
+// ComputeID hashes the row's content over the identity columns, or every
+// column when idCols is nil. Identical lines hash the same; see rowIdentity
+// for the ID that tells them apart.
+func (r renderedRow) ComputeID(idCols []int) uint64 {
+	return r.contentID(idCols, rowIDScheme)
+}
+
+// contentID hashes the row's content as identity scheme did; see rowIDScheme.
+func (r renderedRow) contentID(idCols []int, scheme int) uint64 {
 	h := fnv.New64a()
-	for _, col := range r.cols {
-		norm := strings.ToLower(strings.TrimSpace(col))
-		h.Write([]byte(norm))
+	if idCols == nil {
+		cols := r.cols
+		if scheme >= 2 {
+			// a row padded when the header grows keeps its ID
+			for len(cols) > 0 && strings.TrimSpace(cols[len(cols)-1]) == "" {
+				cols = cols[:len(cols)-1]
+			}
+		}
+		for _, col := range cols {
+			h.Write([]byte(strings.ToLower(strings.TrimSpace(col))))
+			h.Write([]byte{0})
+		}
+		return h.Sum64()
+	}
+	for _, i := range idCols {
+		if i < len(r.cols) {
+			h.Write([]byte(strings.ToLower(strings.TrimSpace(r.cols[i]))))
+		}
 		h.Write([]byte{0})
 	}
 	return h.Sum64()
 }
 
+// rowIDScheme is recorded in snapshots and meta files. Scheme 0 keyed rows
+// on their content alone, so every copy of a repeated line shared one ID.
+// Scheme 1 counted trailing empty cells, so a JSON Lines record read before
+// the header grew hashed differently once it was padded.
+const rowIDScheme = 2
+
+// rowID tells apart rows with identical content. The first copy keeps the
+// plain content hash, so unique rows keep the IDs older snapshots gave them;
+// the nth repeat mixes in its occurrence count.
+func rowID(content uint64, occurrence int) uint64 {
+	if occurrence == 0 {
+		return content
+	}
+	h := fnv.New64a()
+	var buf [16]byte
+	binary.LittleEndian.PutUint64(buf[:8], content)
+	binary.LittleEndian.PutUint64(buf[8:], uint64(occurrence))
+	h.Write(buf[:])
+	return h.Sum64()
+}
+
+// rowIdentity hands out row IDs in source order, counting repeats of each
+// line, so re-importing the same log gives the same IDs again.
+type rowIdentity struct {
+	cols   []int // identity columns, nil for all
+	scheme int
+	seen   map[uint64]int
+}
+
+func newRowIdentity(idCols []int) *rowIdentity {
+	return newSchemeRowIdentity(idCols, rowIDScheme)
+}
+
+// newSchemeRowIdentity hands out the IDs an older rowIDScheme gave, to match
+// annotations saved under it.
+func newSchemeRowIdentity(idCols []int, scheme int) *rowIdentity {
+	return &rowIdentity{cols: idCols, scheme: scheme, seen: make(map[uint64]int)}
+}
+
+// assign sets r.id from its content and how often that content came before.
+func (ri *rowIdentity) assign(r *renderedRow) {
+	content := r.contentID(ri.cols, ri.scheme)
+	if ri.scheme == 0 {
+		r.id = content
+		return
+	}
+	r.id = rowID(content, ri.seen[content])
+	ri.seen[content]++
+}
+
+// assignRowIDs recomputes the ID of every row, in order.
+func assignRowIDs(rows []renderedRow, idCols []int) {
+	ids := newRowIdentity(idCols)
+	for i := range rows {
+		ids.assign(&rows[i])
+	}
+}
+
+// schemeRowIDs are the IDs rows had under an older rowIDScheme.
+func schemeRowIDs(rows []renderedRow, idCols []int, scheme int) []uint64 {
+	ids := newSchemeRowIdentity(idCols, scheme)
+	old := make([]uint64, len(rows))
+	for i, r := range rows {
+		again := renderedRow{cols: r.cols}
+		ids.assign(&again)
+		old[i] = again.id
+	}
+	return old
+}
+
+// rekeyAnnotations moves annotations from the IDs rows had, oldIDs, to the
+// ones they carry now. Annotations that match no row keep their key.
+func rekeyAnnotations[V any](rows []renderedRow, oldIDs []uint64, in map[uint64]V) map[uint64]V {
+	out := make(map[uint64]V, len(in))
+	moved := make(map[uint64]bool)
+	for i, r := range rows {
+		if v, ok := in[oldIDs[i]]; ok {
+			out[r.id] = v
+			moved[oldIDs[i]] = true
+		}
+	}
+	for k, v := range in {
+		if _, taken := out[k]; !moved[k] && !taken {
+			out[k] = v
+		}
+	}
+	return out
+}
+
+// migrateRowIDs moves the marks and comments from the IDs the rows had under
+// an older rowIDScheme to the ones they carry now.
+func (m *model) migrateRowIDs(oldIDs []uint64) {
+	m.touchSession()
+	m.data.markedRows = rekeyAnnotations(m.data.rows, oldIDs, m.data.markedRows)
+	m.data.commentRows = rekeyAnnotations(m.data.rows, oldIDs, m.data.commentRows)
+}
+
 func (r *renderedRow) Join(sep string) string {
 	var b strings.Builder
 
@@ -58,7 +179,11 @@ func (r *renderedRow) Render(style lipgloss.Style, colsMeta []ColumnMeta) string
 			continue
 		}
 
-		cell := style.Width(meta.Width).Render(text)
+		cellStyle := style.Width(meta.Width)
+		if meta.Type.numeric() {
+			cellStyle = cellStyle.Align(lipgloss.Right)
+		}
+		cell := cellStyle.Render(text)
 		rendered = append(rendered, cell)
 	}
 
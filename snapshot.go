@@ -1,33 +1,59 @@
 package main
 
 import (
-	"encoding/csv"
 	"encoding/json"
 	"fmt"
-	"os"
+	"path/filepath"
 	"strconv"
 	"time"
+
+	"github.com/andareed/siftly-hostlog/logging"
 )
 
 // --- Wire format ---
 
-const snapshotVersion = 1
+// snapshotVersion 2 stores rows as plain arrays, or leaves them out and
+// refers to the source file instead. Version 1 is still read; see
+// loadSnapshotV1.
+const snapshotVersion = 2
 
-type renderedRowDTO struct {
-	Cols          []string `json:"cols"`
-	Height        int      `json:"height"`
-	ID            uint64   `json:"id"`
-	OriginalIndex int      `json:"originalIndex"`
-}
+// metaVersion is the format of the annotations files SaveMeta writes.
+const metaVersion = 1
 
 type snapshotDTO struct {
-	Version  int               `json:"version"`
-	Header   []ColumnMeta      `json:"header"`
-	Rows     []renderedRowDTO  `json:"rows"`
+	Version int           `json:"version"`
+	Source  *sourceRefDTO `json:"source,omitempty"` // set instead of rows when the source file is referred to
+	Header  []columnDTO   `json:"header"`
+	Rows    [][]string    `json:"rows,omitempty"`
+	IDs     []uint64      `json:"ids,omitempty"`     // only when the rows do not hash to their IDs
+	Flagged []int         `json:"flagged,omitempty"` // positions of rows repaired by the lenient loader
+	snapshotState
+}
+
+// columnDTO keeps what the user can change about a column; the layout is
+// worked out again on load.
+type columnDTO struct {
+	Name string     `json:"name"`
+	Type ColumnType `json:"type,omitempty"` // only a type set with :type
+}
+
+// snapshotState is the session state both snapshot versions carry.
+type snapshotState struct {
 	Marked   map[string]string `json:"marked"`   // MarkColor as string; uint64 keys stringified
 	Comments map[string]string `json:"comments"` // uint64 keys stringified
 	TimeWin  *timeWindowDTO    `json:"timeWindow,omitempty"`
+	Filter   string            `json:"filter,omitempty"` // regex and @Column terms as typed
 	Note     string            `json:"note,omitempty"`
+
+	TimeColumn string   `json:"timeColumn,omitempty"`
+	TimeLayout string   `json:"timeLayout,omitempty"` // per-file layout, empty to try the configured list
+	TimeRef    string   `json:"timeRef,omitempty"`    // supplies the year for layouts without one
+	Profile    string   `json:"profile,omitempty"`    // set for text logs read through a parser profile
+	SourceZone string   `json:"sourceZone,omitempty"` // --tz the file was loaded with
+	SortColumn string   `json:"sortColumn,omitempty"` // empty for source order
+	SortDesc   bool     `json:"sortDesc,omitempty"`
+	IDScheme   int      `json:"idScheme,omitempty"`  // see rowIDScheme; absent in older snapshots
+	IDColumns  []string `json:"idColumns,omitempty"` // row identity columns, empty for all
 }
 
 type timeWindowDTO struct {
@@ -37,31 +63,15 @@ type timeWindowDTO struct {
 }
 
 type metaOnlyDTO struct {
-	Version  int               `json:"version"`
-	Marked   map[string]string `json:"marked"`
-	Comments map[string]string `json:"comments"`
+	Version   int               `json:"version"`
+	Marked    map[string]string `json:"marked"`
+	Comments  map[string]string `json:"comments"`
+	IDScheme  int               `json:"idScheme,omitempty"`
+	IDColumns []string          `json:"idColumns,omitempty"`
 }
 
 // --- Conversions ---
 
-func toDTORow(r renderedRow) renderedRowDTO {
-	return renderedRowDTO{
-		Cols:          append([]string(nil), r.cols...),
-		Height:        r.height,
-		ID:            r.id,
-		OriginalIndex: r.originalIndex,
-	}
-}
-
-func fromDTORow(d renderedRowDTO) renderedRow {
-	return renderedRow{
-		cols:          append([]string(nil), d.Cols...),
-		height:        d.Height,
-		id:            d.ID,
-		originalIndex: d.OriginalIndex,
-	}
-}
-
 func u64KeyToStringMarkMap(in map[uint64]MarkColor) map[string]string {
 	out := make(map[string]string, len(in))
 	for k, v := range in {
@@ -114,226 +124,437 @@ func sanitizeMarkColor(s string) MarkColor {
 
 // --- Public API ---
 
-// ExportModel writes the *currently filtered* rows to a CSV file,
-// including mark color and comment as additional columns.
-func ExportModel(m *model, path string) error {
-	// Open file
-	f, err := os.Create(path)
+// SaveModel writes the model to a compact JSON snapshot, gzip or zstd
+// compressed when path ends in .gz or .zst, keeping the file it replaces as a
+// backup. With snapshotRows set to "source" the rows are left out and the
+// file they came from is referred to instead, as long as it has not changed
+// since it was loaded.
+func SaveModel(m *model, path string) error {
+	data, err := encodeSnapshot(m, path)
 	if err != nil {
-		return fmt.Errorf("open export file: %w", err)
+		return err
 	}
-	defer f.Close()
-
-	w := csv.NewWriter(f)
-	defer w.Flush()
-
-	// Build header: original columns + Mark + Comment
-	header := make([]string, 0, len(m.data.header)+2)
-	for _, col := range m.data.header {
-		header = append(header, col.Name)
+	if err := rotateBackups(path, snapshotBackups); err != nil {
+		logging.Warnf("SaveModel: keeping a backup of %s: %v", path, err)
 	}
-	header = append(header, "Mark", "Comment")
+	return writeOutputFile(path, data)
+}
 
-	if err := w.Write(header); err != nil {
-		return fmt.Errorf("write header: %w", err)
-	}
+// encodeSnapshot builds the snapshot SaveModel writes to path.
+func encodeSnapshot(m *model, path string) ([]byte, error) {
+	return m.snapshotEncoder(path)()
+}
 
-	// Decide which indices to export:
-	// if filteredIndices is empty, fall back to all rows.
-	indices := m.data.filteredIndices
-	if len(indices) == 0 {
-		indices = make([]int, len(m.data.rows))
-		for i := range m.data.rows {
-			indices[i] = i
+// snapshotEncoder takes what a snapshot of m needs and returns the step that
+// encodes it. That step reads nothing else of m, so autosave runs it off the
+// UI loop: it does the slow part, hashing the source or marshalling the rows.
+func (m *model) snapshotEncoder(path string) func() ([]byte, error) {
+	dto := snapshotDTO{
+		Version:       snapshotVersion,
+		Header:        make([]columnDTO, len(m.data.header)),
+		snapshotState: m.snapshotState(),
+	}
+	for i, col := range m.data.header {
+		dto.Header[i] = columnDTO{Name: col.Name}
+		if col.TypeSet {
+			dto.Header[i].Type = col.Type
 		}
 	}
 
-	// Export each visible row
-	for _, idx := range indices {
-		// sanity check
-		if idx < 0 || idx >= len(m.data.rows) {
-			return fmt.Errorf("filtered index %d out of range", idx)
-		}
-		r := m.data.rows[idx]
-
-		// row data: original cols
-		out := append([]string(nil), r.cols...)
-
-		// append mark + comment using the row's id
-		mark := ""
-		if c, ok := m.data.markedRows[r.id]; ok {
-			mark = string(c)
+	// Copy the rows now: a followed file pads them when it gains columns,
+	// which would race with the step reading them. The cells are shared,
+	// padding only appends past the copied length.
+	src, idCols := m.data.source, m.idColumnIndices()
+	rows := make([]renderedRow, len(m.data.rows))
+	copy(rows, m.data.rows)
+	return func() ([]byte, error) {
+		ref, err := src.ref(path)
+		if err != nil {
+			logging.Warnf("SaveModel: storing the rows, cannot refer to the source: %v", err)
 		}
-
-		comment := ""
-		if c, ok := m.data.commentRows[r.id]; ok {
-			comment = c
+		if ref != nil {
+			dto.Source = ref
+		} else {
+			dto.Rows, dto.IDs, dto.Flagged = snapshotRows(rows, idCols)
 		}
+		return json.Marshal(dto)
+	}
+}
 
-		out = append(out, mark, comment)
+// snapshotState collects what a snapshot keeps besides the rows.
+func (m *model) snapshotState() snapshotState {
+	s := snapshotState{
+		Marked:     u64KeyToStringMarkMap(m.data.markedRows),
+		Comments:   u64KeyToStringStringMap(m.data.commentRows),
+		Filter:     m.data.filterPattern,
+		TimeColumn: m.data.timeColumn,
+		TimeLayout: m.data.timeLayout,
+		Profile:    m.data.profile,
+		SourceZone: m.data.sourceZoneName,
+		SortColumn: m.data.sortColumn,
+		SortDesc:   m.data.sortDesc,
+		IDScheme:   rowIDScheme,
+		IDColumns:  m.data.idColumns,
+		TimeWin: &timeWindowDTO{
+			Enabled: m.data.timeWindow.Enabled,
+			Start:   m.data.timeWindow.Start.Format(time.RFC3339Nano),
+			End:     m.data.timeWindow.End.Format(time.RFC3339Nano),
+		},
+	}
+	if !m.data.timeRef.IsZero() {
+		s.TimeRef = m.data.timeRef.Format(time.RFC3339Nano)
+	}
+	return s
+}
 
-		if err := w.Write(out); err != nil {
-			return fmt.Errorf("write row %d: %w", idx, err)
+// snapshotRows returns the cells of every row, the positions of flagged rows
+// and, only when recomputing them would not give the same, the row IDs.
+func snapshotRows(from []renderedRow, idCols []int) (rows [][]string, ids []uint64, flagged []int) {
+	rows = make([][]string, len(from))
+	ids = make([]uint64, len(from))
+	check := newRowIdentity(idCols)
+	recomputable := true
+	for i, r := range from {
+		rows[i] = r.cols
+		ids[i] = r.id
+		if r.flagged {
+			flagged = append(flagged, i)
 		}
+		again := renderedRow{cols: r.cols}
+		check.assign(&again)
+		recomputable = recomputable && again.id == r.id
 	}
+	if recomputable {
+		ids = nil
+	}
+	return rows, ids, flagged
+}
 
-	w.Flush()
-	if err := w.Error(); err != nil {
-		return fmt.Errorf("flush csv: %w", err)
+// LoadModel replaces the contents of m with the snapshot from path.
+func LoadModel(m *model, path string, opts loadOptions) error {
+	data, err := readInputFile(path)
+	if err != nil {
+		return err
 	}
+	return loadSnapshotData(m, data, filepath.Dir(path), opts)
+}
 
-	return nil
+// loadSnapshotData replaces the contents of m with an already-read snapshot.
+// A source the snapshot refers to is looked for relative to dir.
+func loadSnapshotData(m *model, data []byte, dir string, opts loadOptions) error {
+	var head struct {
+		Version int `json:"version"`
+	}
+	if err := json.Unmarshal(data, &head); err != nil {
+		return err
+	}
+	switch head.Version {
+	case 1:
+		logging.Infof("loadSnapshotData: migrating a version 1 snapshot")
+		return loadSnapshotV1(m, data)
+	case snapshotVersion:
+		return loadSnapshotV2(m, data, dir, opts)
+	}
+	return fmt.Errorf("snapshot version %d not supported (want 1 to %d)", head.Version, snapshotVersion)
 }
 
-// SaveModel writes the entire model to a JSON file.
-func SaveModel(m *model, path string) error {
-	dto := snapshotDTO{
-		Version:  snapshotVersion,
-		Header:   nil, // filled below
-		Rows:     make([]renderedRowDTO, 0, len(m.data.rows)),
-		Marked:   u64KeyToStringMarkMap(m.data.markedRows),
-		Comments: u64KeyToStringStringMap(m.data.commentRows),
+func loadSnapshotV2(m *model, data []byte, dir string, opts loadOptions) error {
+	var dto snapshotDTO
+	if err := json.Unmarshal(data, &dto); err != nil {
+		return err
 	}
-	dto.TimeWin = &timeWindowDTO{
-		Enabled: m.data.timeWindow.Enabled,
-		Start:   m.data.timeWindow.Start.Format(time.RFC3339Nano),
-		End:     m.data.timeWindow.End.Format(time.RFC3339Nano),
+	if dto.Source != nil {
+		return loadSnapshotSource(m, dto, dir, opts)
 	}
 
-	// Copy header metadata
-	if len(m.data.header) > 0 {
-		dto.Header = make([]ColumnMeta, len(m.data.header))
-		copy(dto.Header, m.data.header)
+	names := make([]string, len(dto.Header))
+	m.data.header = make([]ColumnMeta, len(dto.Header))
+	for i, col := range dto.Header {
+		names[i] = col.Name
+		m.data.header[i] = newColumnMeta(i, col.Name)
+		m.data.header[i].Type, m.data.header[i].TypeSet = col.Type, col.Type != TypeUnset
 	}
+	markEmptyColumns(m.data.header, append([][]string{names}, dto.Rows...))
 
-	// Copy rows
-	for _, r := range m.data.rows {
-		dto.Rows = append(dto.Rows, toDTORow(r))
+	m.data.rows = make([]renderedRow, len(dto.Rows))
+	for i, cols := range dto.Rows {
+		m.data.rows[i] = renderedRow{cols: cols, height: 1, originalIndex: i + 1}
+	}
+	for _, i := range dto.Flagged {
+		if i >= 0 && i < len(m.data.rows) {
+			m.data.rows[i].flagged = true
+		}
 	}
 
-	data, err := json.MarshalIndent(dto, "", "  ")
-	if err != nil {
+	if err := dto.restore(m); err != nil {
 		return err
 	}
-	return os.WriteFile(path, data, 0o600)
+	switch len(dto.IDs) {
+	case 0:
+		idCols := m.idColumnIndices()
+		assignRowIDs(m.data.rows, idCols)
+		if dto.IDScheme < rowIDScheme {
+			m.migrateRowIDs(schemeRowIDs(m.data.rows, idCols, dto.IDScheme))
+		}
+	case len(m.data.rows):
+		for i := range m.data.rows {
+			m.data.rows[i].id = dto.IDs[i]
+		}
+	default:
+		return fmt.Errorf("snapshot has %d row IDs for %d rows", len(dto.IDs), len(m.data.rows))
+	}
+	m.data.unmatchedNotes = countUnmatched(m.data.rows, m.data.markedRows, m.data.commentRows)
+	return nil
 }
 
-// LoadModel replaces the contents of m with the snapshot from path.
-func LoadModel(m *model, path string) error {
-	data, err := os.ReadFile(path)
-	if err != nil {
+// restoreOnto applies the saved state to rows m has read itself, from the
+// snapshot's source or when recovering an autosave. The rows stay keyed as
+// they were read, and column types carry over by name.
+func (d snapshotDTO) restoreOnto(m *model) error {
+	idColumns := m.data.idColumns
+	if err := d.restore(m); err != nil {
 		return err
 	}
-	var dto snapshotDTO
-	if err := json.Unmarshal(data, &dto); err != nil {
-		return err
+	if !equalFoldNames(d.IDColumns, idColumns) {
+		logging.Warnf("restoreOnto: rows keyed on %v rather than %v, marks may not match", idColumns, d.IDColumns)
 	}
-	if dto.Version != snapshotVersion {
-		return fmt.Errorf("snapshot version %d not supported (want %d)", dto.Version, snapshotVersion)
+	m.data.idColumns = idColumns
+	for _, col := range d.Header {
+		if i := findColumnIndex(m.data.header, col.Name); i >= 0 && col.Type != TypeUnset {
+			m.data.header[i].Type, m.data.header[i].TypeSet = col.Type, true
+		}
 	}
-
-	// Restore header
-	m.data.header = m.data.header[:0]
-	if len(dto.Header) > 0 {
-		m.data.header = make([]ColumnMeta, len(dto.Header))
-		copy(m.data.header, dto.Header)
+	if d.IDScheme < rowIDScheme {
+		m.migrateRowIDs(schemeRowIDs(m.data.rows, m.idColumnIndices(), d.IDScheme))
 	}
+	return nil
+}
 
-	// Restore rows
-	m.data.rows = m.data.rows[:0]
-	for _, dr := range dto.Rows {
-		m.data.rows = append(m.data.rows, fromDTORow(dr))
+// restore applies the saved session state to m.
+func (s snapshotState) restore(m *model) error {
+	var err error
+	if m.data.markedRows, err = parseUintKeyMapMark(s.Marked); err != nil {
+		return err
+	}
+	if m.data.commentRows, err = parseUintKeyMapString(s.Comments); err != nil {
+		return err
 	}
+	m.data.idColumns = s.IDColumns
+	m.data.filterPattern = s.Filter // compiled in InitialiseUI
 
-	// Restore marks/comments
-	var errMarks, errComments error
-	m.data.markedRows, errMarks = parseUintKeyMapMark(dto.Marked)
-	if errMarks != nil {
-		return errMarks
+	m.data.timeColumn = s.TimeColumn
+	m.data.profile = s.Profile
+	m.data.timeLayout = s.TimeLayout
+	m.data.sortColumn, m.data.sortDesc = s.SortColumn, s.SortDesc
+	m.data.sourceZone, m.data.sourceZoneName = nil, ""
+	if s.SourceZone != "" {
+		loc, err := parseZone(s.SourceZone)
+		if err != nil {
+			return fmt.Errorf("invalid sourceZone: %w", err)
+		}
+		m.data.sourceZone, m.data.sourceZoneName = loc, s.SourceZone
 	}
-	m.data.commentRows, errComments = parseUintKeyMapString(dto.Comments)
-	if errComments != nil {
-		return errComments
+	m.data.timeRef = time.Time{}
+	if s.TimeRef != "" {
+		ref, err := time.Parse(time.RFC3339Nano, s.TimeRef)
+		if err != nil {
+			return fmt.Errorf("invalid timeRef: %w", err)
+		}
+		m.data.timeRef = ref
 	}
 
 	// Restore time window (bounds recomputed in InitialiseUI)
-	if dto.TimeWin != nil {
-		start, err := time.Parse(time.RFC3339Nano, dto.TimeWin.Start)
-		if err != nil && dto.TimeWin.Start != "" {
+	if s.TimeWin != nil {
+		start, err := time.Parse(time.RFC3339Nano, s.TimeWin.Start)
+		if err != nil && s.TimeWin.Start != "" {
 			return fmt.Errorf("invalid timeWindow start: %w", err)
 		}
-		end, err := time.Parse(time.RFC3339Nano, dto.TimeWin.End)
-		if err != nil && dto.TimeWin.End != "" {
+		end, err := time.Parse(time.RFC3339Nano, s.TimeWin.End)
+		if err != nil && s.TimeWin.End != "" {
 			return fmt.Errorf("invalid timeWindow end: %w", err)
 		}
 		m.data.timeWindow = TimeWindow{
-			Enabled: dto.TimeWin.Enabled,
+			Enabled: s.TimeWin.Enabled,
 			Start:   start,
 			End:     end,
 		}
 	}
-
 	return nil
 }
 
+// countUnmatched counts rows marked or commented on that are not among rows.
+func countUnmatched(rows []renderedRow, marks map[uint64]MarkColor, comments map[uint64]string) int {
+	present := make(map[uint64]bool, len(rows))
+	for _, r := range rows {
+		present[r.id] = true
+	}
+	unmatched := make(map[uint64]bool)
+	for id := range marks {
+		if !present[id] {
+			unmatched[id] = true
+		}
+	}
+	for id := range comments {
+		if !present[id] {
+			unmatched[id] = true
+		}
+	}
+	return len(unmatched)
+}
+
 // SaveMeta writes only marks/comments so they can be re-applied after a fresh CSV import.
 func SaveMeta(m *model, path string) error {
 	dto := metaOnlyDTO{
-		Version:  snapshotVersion,
-		Marked:   u64KeyToStringMarkMap(m.data.markedRows),
-		Comments: u64KeyToStringStringMap(m.data.commentRows),
+		Version:   metaVersion,
+		Marked:    u64KeyToStringMarkMap(m.data.markedRows),
+		Comments:  u64KeyToStringStringMap(m.data.commentRows),
+		IDScheme:  rowIDScheme,
+		IDColumns: m.data.idColumns,
 	}
 	data, err := json.MarshalIndent(dto, "", "  ")
 	if err != nil {
 		return err
 	}
-	return os.WriteFile(path, data, 0o600)
+	if err := rotateBackups(path, snapshotBackups); err != nil {
+		logging.Warnf("SaveMeta: keeping a backup of %s: %v", path, err)
+	}
+	return writeOutputFile(path, data)
+}
+
+// metaMerge counts the outcome of LoadMeta, one per mark or comment in the file.
+type metaMerge struct {
+	Applied     int
+	Skipped     int // no row in the session has the key
+	Conflicting int // the row already has a different mark or comment, which is kept
+}
+
+func (r metaMerge) String() string {
+	return fmt.Sprintf("%d applied, %d skipped (no matching row), %d conflicting (kept the session's)", r.Applied, r.Skipped, r.Conflicting)
+}
+
+type mergeOutcome int
+
+const (
+	mergeSkipped mergeOutcome = iota // the zero value: no row seen yet
+	mergeApplied
+	mergeConflict
+)
+
+// metaAnnotation identifies one mark or comment in an annotations file.
+type metaAnnotation struct {
+	key     uint64
+	comment bool
 }
 
-// LoadMeta merges marks/comments into m, only for rows currently present (by ID).
-func LoadMeta(m *model, path string) error {
-	data, err := os.ReadFile(path)
+// metaFile is an annotations file being matched against rows, which may
+// still be streaming in. Rows are keyed on the identity columns the file was
+// saved with, so a re-export that adds or changes other columns keeps its
+// annotations. Older files keyed rows on content, and such a key covers
+// every copy of a repeated line.
+type metaFile struct {
+	marks    map[uint64]MarkColor
+	comments map[uint64]string
+	ids      *rowIdentity
+	outcomes map[metaAnnotation]mergeOutcome
+}
+
+// readMetaFile parses an annotations file written by SaveMeta.
+func readMetaFile(path string, header []ColumnMeta) (*metaFile, error) {
+	data, err := readInputFile(path)
 	if err != nil {
-		return err
+		return nil, err
 	}
 	var dto metaOnlyDTO
 	if err := json.Unmarshal(data, &dto); err != nil {
-		return err
+		return nil, err
+	}
+	if dto.Version != metaVersion {
+		return nil, fmt.Errorf("meta version %d not supported (want %d)", dto.Version, metaVersion)
+	}
+	f := &metaFile{
+		outcomes: make(map[metaAnnotation]mergeOutcome),
+	}
+	if f.marks, err = parseUintKeyMapMark(dto.Marked); err != nil {
+		return nil, err
+	}
+	if f.comments, err = parseUintKeyMapString(dto.Comments); err != nil {
+		return nil, err
 	}
-	if dto.Version != snapshotVersion {
-		return fmt.Errorf("meta version %d not supported (want %d)", dto.Version, snapshotVersion)
+	idCols, missing := resolveIDColumns(header, dto.IDColumns)
+	if len(missing) > 0 {
+		logging.Warnf("readMetaFile: identity columns %v not in the header", missing)
 	}
+	f.ids = newSchemeRowIdentity(idCols, dto.IDScheme)
+	return f, nil
+}
 
+// apply merges the file's annotations into m for rows, which must be passed
+// in source order across calls. Annotations already in the session win.
+func (f *metaFile) apply(m *model, rows []renderedRow) {
+	m.touchSession()
 	if m.data.markedRows == nil {
 		m.data.markedRows = make(map[uint64]MarkColor)
 	}
 	if m.data.commentRows == nil {
 		m.data.commentRows = make(map[uint64]string)
 	}
+	for _, r := range rows {
+		keyed := r
+		f.ids.assign(&keyed)
+		k := keyed.id
+		if c, ok := f.marks[k]; ok {
+			f.note(metaAnnotation{key: k}, mergeAnnotation(m.data.markedRows, r.id, c))
+		}
+		if c, ok := f.comments[k]; ok {
+			f.note(metaAnnotation{key: k, comment: true}, mergeAnnotation(m.data.commentRows, r.id, c))
+		}
+	}
+}
 
-	present := make(map[uint64]struct{}, len(m.data.rows))
-	for _, r := range m.data.rows {
-		present[r.id] = struct{}{}
+// note records the outcome for one row; a conflict on any row sticks.
+func (f *metaFile) note(a metaAnnotation, outcome mergeOutcome) {
+	if f.outcomes[a] != mergeConflict {
+		f.outcomes[a] = outcome
 	}
+}
 
-	for ks, vs := range dto.Marked {
-		k, err := strconv.ParseUint(ks, 10, 64)
-		if err != nil {
-			return err
-		}
-		if _, ok := present[k]; ok {
-			m.data.markedRows[k] = sanitizeMarkColor(vs)
+// result counts every annotation in the file by how it went.
+func (f *metaFile) result() metaMerge {
+	var res metaMerge
+	tally := func(a metaAnnotation) {
+		switch f.outcomes[a] {
+		case mergeApplied:
+			res.Applied++
+		case mergeConflict:
+			res.Conflicting++
+		default:
+			res.Skipped++
 		}
 	}
-	for ks, vs := range dto.Comments {
-		k, err := strconv.ParseUint(ks, 10, 64)
-		if err != nil {
-			return err
-		}
-		if _, ok := present[k]; ok {
-			m.data.commentRows[k] = vs
-		}
+	for k := range f.marks {
+		tally(metaAnnotation{key: k})
+	}
+	for k := range f.comments {
+		tally(metaAnnotation{key: k, comment: true})
 	}
+	return res
+}
 
-	return nil
+// mergeAnnotation sets v on row id unless it already has a different value.
+func mergeAnnotation[V comparable](into map[uint64]V, id uint64, v V) mergeOutcome {
+	if cur, ok := into[id]; ok && cur != v {
+		return mergeConflict
+	}
+	into[id] = v
+	return mergeApplied
+}
+
+// LoadMeta merges marks/comments into m for the rows currently present.
+func LoadMeta(m *model, path string) (metaMerge, error) {
+	f, err := readMetaFile(path, m.data.header)
+	if err != nil {
+		return metaMerge{}, err
+	}
+	f.apply(m, m.data.rows)
+	return f.result(), nil
 }
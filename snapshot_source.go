@@ -0,0 +1,196 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/andareed/siftly-hostlog/logging"
+)
+
+// How SaveModel stores rows, from the config file or --snapshot-rows.
+const (
+	snapshotRowsInline = "inline" // rows go into the snapshot
+	snapshotRowsSource = "source" // the snapshot refers to the file they came from
+)
+
+var snapshotRowsMode = snapshotRowsInline
+
+func parseSnapshotRows(s string) (string, error) {
+	switch strings.ToLower(strings.TrimSpace(s)) {
+	case "", snapshotRowsInline:
+		return snapshotRowsInline, nil
+	case snapshotRowsSource:
+		return snapshotRowsSource, nil
+	}
+	return "", fmt.Errorf("invalid snapshot rows %q (want inline or source)", s)
+}
+
+// sourceFile is the file the rows were read from, as it was when loaded, so
+// a snapshot can refer to it instead of carrying the rows.
+type sourceFile struct {
+	path    string
+	size    int64
+	modTime time.Time
+	opts    loadOptions
+
+	mu      sync.Mutex // autosave hashes off the UI loop
+	sum     string     // SHA-256 of the file when it had sumSize and sumMod
+	sumSize int64
+	sumMod  time.Time
+}
+
+// sourceRefDTO points a snapshot at its source file. The options are the
+// ones given when it was loaded; the profile, time layout, zone and identity
+// columns are in the snapshot state.
+type sourceRefDTO struct {
+	Path      string `json:"path"` // relative to the snapshot when it sits beside or below it
+	SHA256    string `json:"sha256"`
+	Delimiter string `json:"delimiter,omitempty"`
+	Encoding  string `json:"encoding,omitempty"`
+	Lenient   bool   `json:"lenient,omitempty"`
+	SkipBad   bool   `json:"skipBad,omitempty"`
+}
+
+// ref describes the source file for a snapshot saved to path, or returns
+// nil when the rows have to be stored: the mode is inline, or the rows came
+// from stdin, several files or a followed file (src is nil). The error says
+// why a source that was asked for cannot be used.
+func (src *sourceFile) ref(path string) (*sourceRefDTO, error) {
+	if snapshotRowsMode != snapshotRowsSource || src == nil {
+		return nil, nil
+	}
+	info, err := os.Stat(src.path)
+	if err != nil {
+		return nil, err
+	}
+	if info.Size() != src.size || !info.ModTime().Equal(src.modTime) {
+		return nil, fmt.Errorf("%s has changed since it was loaded", src.path)
+	}
+	sum, err := src.sha256(info)
+	if err != nil {
+		return nil, err
+	}
+
+	abs, err := filepath.Abs(src.path)
+	if err != nil {
+		return nil, err
+	}
+	ref := &sourceRefDTO{
+		Path:     abs,
+		SHA256:   sum,
+		Encoding: src.opts.encoding,
+		Lenient:  src.opts.lenient,
+		SkipBad:  src.opts.skipBad,
+	}
+	if src.opts.delimiter != 0 {
+		ref.Delimiter = string(src.opts.delimiter)
+	}
+	if dir, err := filepath.Abs(filepath.Dir(path)); err == nil {
+		if rel, err := filepath.Rel(dir, abs); err == nil && !strings.HasPrefix(rel, "..") {
+			ref.Path = rel
+		}
+	}
+	return ref, nil
+}
+
+// sha256 hashes the source once for each size and modification time it has,
+// so saving a snapshot again does not read a large file again.
+func (src *sourceFile) sha256(info os.FileInfo) (string, error) {
+	src.mu.Lock()
+	defer src.mu.Unlock()
+	if src.sum != "" && src.sumSize == info.Size() && src.sumMod.Equal(info.ModTime()) {
+		return src.sum, nil
+	}
+	sum, err := fileSHA256(src.path)
+	if err != nil {
+		return "", err
+	}
+	src.sum, src.sumSize, src.sumMod = sum, info.Size(), info.ModTime()
+	return sum, nil
+}
+
+// fileSHA256 hashes the file as it is on disk, compressed or not.
+func fileSHA256(path string) (string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+	h := sha256.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return "", fmt.Errorf("hash %s: %w", path, err)
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// loadSnapshotSource reads the rows of a snapshot back from the file it
+// refers to, which must be unchanged, and then applies the saved state.
+func loadSnapshotSource(m *model, dto snapshotDTO, dir string, opts loadOptions) error {
+	ref := dto.Source
+	path := ref.Path
+	if !filepath.IsAbs(path) {
+		path = filepath.Join(dir, path)
+	}
+	sum, err := fileSHA256(path)
+	if err != nil {
+		return fmt.Errorf("snapshot source: %w", err)
+	}
+	if sum != ref.SHA256 {
+		return fmt.Errorf("snapshot source %s has changed since the snapshot was saved (sha256 %s, want %s)", path, sum, ref.SHA256)
+	}
+
+	opts.follow = false
+	opts.encoding = ref.Encoding
+	opts.lenient, opts.skipBad = ref.Lenient, ref.SkipBad
+	opts.delimiter = 0
+	if ref.Delimiter != "" {
+		opts.delimiter = []rune(ref.Delimiter)[0]
+	}
+	opts.timeLayout = dto.TimeLayout
+	opts.timeKey = dto.TimeColumn
+	opts.idColumns = dto.IDColumns
+	opts.profile = nil
+	if dto.Profile != "" {
+		if opts.profile, err = findProfile(opts.profiles, dto.Profile); err != nil {
+			return fmt.Errorf("snapshot source: %w", err)
+		}
+	}
+	opts.tz, opts.tzName = nil, ""
+	if dto.SourceZone != "" {
+		if opts.tz, err = parseZone(dto.SourceZone); err != nil {
+			return fmt.Errorf("invalid sourceZone: %w", err)
+		}
+		opts.tzName = dto.SourceZone
+	}
+
+	logging.Infof("loadSnapshotSource: reading the rows from %s", path)
+	loaded, err := loadModelAuto(path, opts)
+	if err != nil {
+		return fmt.Errorf("snapshot source: %w", err)
+	}
+	if loaded.data.source == nil {
+		return fmt.Errorf("snapshot source %s is itself a snapshot", path)
+	}
+	*m = *loaded
+
+	return dto.restoreOnto(m)
+}
+
+func equalFoldNames(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if !strings.EqualFold(a[i], b[i]) {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,75 @@
+package main
+
+import (
+	"encoding/json"
+)
+
+// Version 1 snapshots stored every row as an object along with its render
+// height, and the full column layout. They are still read, and saved again
+// as version 2.
+
+type renderedRowDTO struct {
+	Cols          []string `json:"cols"`
+	Height        int      `json:"height"`
+	ID            uint64   `json:"id"`
+	OriginalIndex int      `json:"originalIndex"`
+	Flagged       bool     `json:"flagged,omitempty"`
+}
+
+type snapshotV1DTO struct {
+	Version int              `json:"version"`
+	Header  []ColumnMeta     `json:"header"`
+	Rows    []renderedRowDTO `json:"rows"`
+	snapshotState
+}
+
+func fromDTORow(d renderedRowDTO) renderedRow {
+	return renderedRow{
+		cols:          append([]string(nil), d.Cols...),
+		height:        d.Height,
+		id:            d.ID,
+		originalIndex: d.OriginalIndex,
+		flagged:       d.Flagged,
+	}
+}
+
+// loadSnapshotV1 replaces the contents of m with a version 1 snapshot.
+func loadSnapshotV1(m *model, data []byte) error {
+	var dto snapshotV1DTO
+	if err := json.Unmarshal(data, &dto); err != nil {
+		return err
+	}
+
+	// Restore header
+	m.data.header = m.data.header[:0]
+	if len(dto.Header) > 0 {
+		m.data.header = make([]ColumnMeta, len(dto.Header))
+		copy(m.data.header, dto.Header)
+	}
+	for i := range m.data.header {
+		// Older snapshots kept the BOM on the first column name
+		m.data.header[i].Name = normalizeHeaderName(m.data.header[i].Name)
+	}
+
+	// Restore rows
+	m.data.rows = m.data.rows[:0]
+	for _, dr := range dto.Rows {
+		m.data.rows = append(m.data.rows, fromDTORow(dr))
+	}
+
+	if err := dto.restore(m); err != nil {
+		return err
+	}
+	if dto.IDScheme < rowIDScheme {
+		// The rows carry the IDs they were annotated under; before scheme 1
+		// repeated lines shared one, and each copy now keeps its annotations.
+		oldIDs := make([]uint64, len(m.data.rows))
+		for i, r := range m.data.rows {
+			oldIDs[i] = r.id
+		}
+		assignRowIDs(m.data.rows, m.idColumnIndices())
+		m.migrateRowIDs(oldIDs)
+	}
+	m.data.unmatchedNotes = countUnmatched(m.data.rows, m.data.markedRows, m.data.commentRows)
+	return nil
+}
@@ -0,0 +1,225 @@
+package main
+
+import (
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// Markers shown in front of the sorted column's name.
+const (
+	sortAscMarker  = "▲"
+	sortDescMarker = "▼"
+)
+
+// sortColumnIndex returns the column rows are sorted by, or -1 for source order.
+func (m *model) sortColumnIndex() int {
+	if m.data.sortColumn == "" {
+		return -1
+	}
+	return findColumnIndex(m.data.header, m.data.sortColumn)
+}
+
+// sortFiltered orders filteredIndices by the sort column.
+func (m *model) sortFiltered() {
+	m.sortIndices(m.data.filteredIndices)
+	s, _ := m.rowSorter()
+	m.data.sortedAs = s.sortSpec
+}
+
+// sortIndices orders indices into m.data.rows by the sort column. The sort
+// is stable, so rows with equal values keep their source order, and rows with
+// no value for the column stay at the bottom in either direction. The time
+// column is ordered by parsed time and sequence number, other columns by
+// their type.
+func (m *model) sortIndices(indices []int) {
+	s, ok := m.rowSorter()
+	if !ok {
+		return
+	}
+	keys := s.keys(indices)
+	slices.SortStableFunc(keys, s.compare)
+	for i, k := range keys {
+		indices[i] = k.idx
+	}
+}
+
+// mergeFiltered sorts the indices appended to filteredIndices from n onwards
+// and merges them into the sorted ones before, so rows streaming in do not
+// re-sort everything. Appended rows come later in the source, so they go
+// after the rows they tie with, as a stable sort of the lot would put them.
+// If the sort column was retyped meanwhile everything is sorted again.
+func (m *model) mergeFiltered(n int) {
+	s, ok := m.rowSorter()
+	if !ok {
+		return
+	}
+	if s.sortSpec != m.data.sortedAs {
+		m.sortFiltered()
+		return
+	}
+	idx := m.data.filteredIndices
+	added := s.keys(idx[n:])
+	slices.SortStableFunc(added, s.compare)
+
+	// Fill from the back: each added row lands after the old rows that do
+	// not sort after it, and those that do move up to make room.
+	old := n
+	for j := len(added) - 1; j >= 0; j-- {
+		k := added[j]
+		i := sort.Search(old, func(i int) bool { return s.compare(s.key(idx[i]), k) > 0 })
+		copy(idx[i+j+1:old+j+1], idx[i:old])
+		idx[i+j] = k.idx
+		old = i
+	}
+}
+
+// sortKey is a row's value in the sort column, parsed once rather than on
+// every comparison.
+type sortKey struct {
+	idx int
+	key any
+	ok  bool
+}
+
+// sortSpec is everything that decides the order of sorted rows.
+type sortSpec struct {
+	col    int
+	t      ColumnType
+	desc   bool
+	byTime bool // the time column, compared by parsed time and sequence
+}
+
+// rowSorter orders rows by one column.
+type rowSorter struct {
+	m *model
+	sortSpec
+}
+
+// rowSorter returns the sorter for the sort column, or false in source order.
+func (m *model) rowSorter() (rowSorter, bool) {
+	col := m.sortColumnIndex()
+	if col < 0 {
+		return rowSorter{}, false
+	}
+	return rowSorter{m: m, sortSpec: sortSpec{
+		col:    col,
+		t:      m.data.header[col].Type,
+		desc:   m.data.sortDesc,
+		byTime: col == m.data.timeColumnIndex,
+	}}, true
+}
+
+func (s rowSorter) keys(indices []int) []sortKey {
+	keys := make([]sortKey, len(indices))
+	for i, idx := range indices {
+		keys[i] = s.key(idx)
+	}
+	return keys
+}
+
+func (s rowSorter) key(idx int) sortKey {
+	if s.byTime {
+		return sortKey{idx: idx}
+	}
+	v := ""
+	if row := s.m.data.rows[idx]; s.col < len(row.cols) {
+		v = row.cols[s.col]
+	}
+	k, ok := s.m.typedKey(s.t, v)
+	if !ok && strings.TrimSpace(v) != "" {
+		// not valid for the type: sort as text after the valid values
+		k = strings.ToLower(strings.TrimSpace(v))
+	}
+	return sortKey{idx: idx, key: k, ok: ok}
+}
+
+func (s rowSorter) dir(c int) int {
+	if s.desc {
+		return -c
+	}
+	return c
+}
+
+func (s rowSorter) compare(ka, kb sortKey) int {
+	if s.byTime {
+		if !s.m.data.rowHasTimes[ka.idx] || !s.m.data.rowHasTimes[kb.idx] {
+			return s.m.compareRowTimes(ka.idx, kb.idx)
+		}
+		return s.dir(s.m.compareRowTimes(ka.idx, kb.idx))
+	}
+	switch {
+	case ka.key == nil || kb.key == nil:
+		// empty cells last
+		if ka.key == nil && kb.key == nil {
+			return 0
+		}
+		if ka.key == nil {
+			return 1
+		}
+		return -1
+	case ka.ok != kb.ok:
+		if ka.ok {
+			return -1
+		}
+		return 1
+	}
+	return s.dir(compareKeys(ka.key, kb.key))
+}
+
+// moveColumnCursor steps the column cursor over the visible columns.
+func (m *model) moveColumnCursor(delta int) {
+	n := len(m.data.header)
+	if n == 0 {
+		return
+	}
+	for i := m.ui.colCursor + delta; i >= 0 && i < n; i += delta {
+		if col := m.data.header[i]; col.Visible && col.Width > 0 {
+			m.ui.colCursor = i
+			return
+		}
+	}
+}
+
+// cycleSort steps the column under the column cursor through ascending,
+// descending and back to source order, keeping the selected row in view.
+func (m *model) cycleSort() tea.Cmd {
+	if m.ui.colCursor < 0 || m.ui.colCursor >= len(m.data.header) {
+		return nil
+	}
+	name := m.data.header[m.ui.colCursor].Name
+	switch {
+	case !strings.EqualFold(m.data.sortColumn, name):
+		m.data.sortColumn, m.data.sortDesc = name, false
+	case !m.data.sortDesc:
+		m.data.sortDesc = true
+	default:
+		m.data.sortColumn, m.data.sortDesc = "", false
+	}
+	m.applyFilter()
+	m.refreshView("sort", false)
+	if m.data.sortColumn == "" {
+		return m.startNotice("Rows in source order", "", noticeDuration)
+	}
+	dir := "ascending"
+	if m.data.sortDesc {
+		dir = "descending"
+	}
+	return m.startNotice(fmt.Sprintf("Sorted by %s, %s", name, dir), "", noticeDuration)
+}
+
+// headerLabel is a column's name as shown in the header, with the sort marker.
+func (m *model) headerLabel(col ColumnMeta) string {
+	if !strings.EqualFold(col.Name, m.data.sortColumn) {
+		return col.Name
+	}
+	marker := sortAscMarker
+	if m.data.sortDesc {
+		marker = sortDescMarker
+	}
+	// padding takes two cells; keep the marker when the name is cut short
+	return truncatePlain(marker+col.Name, max(1, col.Width-2))
+}
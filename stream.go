@@ -0,0 +1,344 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"sync/atomic"
+
+	"github.com/andareed/siftly-hostlog/logging"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+const (
+	// loadFirstBatchSize rows are read before the program starts so the first page is ready.
+	loadFirstBatchSize = 500
+	// loadBatchSize rows are delivered per message while the rest of the file streams in.
+	loadBatchSize = 5000
+)
+
+// recordSource yields one record at a time; io.EOF marks the end of the input.
+type recordSource interface {
+	Read() ([]string, error)
+}
+
+// countingReader tracks how many bytes have been consumed so progress can be reported.
+type countingReader struct {
+	r io.Reader
+	n atomic.Int64
+}
+
+func (c *countingReader) Read(p []byte) (int, error) {
+	n, err := c.r.Read(p)
+	c.n.Add(int64(n))
+	return n, err
+}
+
+func (c *countingReader) Count() int64 {
+	return c.n.Load()
+}
+
+// rowBatchMsg carries a batch of rows from the background reader into Update.
+type rowBatchMsg struct {
+	rows    []renderedRow
+	columns []string // header names first seen in this batch
+	issues  []loadIssue
+	notes   []rowNote // annotations of a re-opened export
+	read    int64
+	idle    bool // sent because the reader caught up with a followed file
+	done    bool
+	err     error
+}
+
+// rowStream reads records on a background goroutine and hands them over in batches.
+type rowStream struct {
+	src     recordSource
+	counter *countingReader
+	closer  io.Closer
+	total   int64 // total bytes, 0 when unknown
+	next    int   // originalIndex of the last row read
+	batches chan rowBatchMsg
+	pending []renderedRow
+	notes   []rowNote
+	ids     *rowIdentity
+	tail    *tailReader // set in --follow mode
+	idled   bool
+}
+
+func newRowStream(src recordSource, counter *countingReader, total int64, closer io.Closer) *rowStream {
+	return &rowStream{
+		src:     src,
+		counter: counter,
+		closer:  closer,
+		total:   total,
+		batches: make(chan rowBatchMsg, 2),
+		ids:     newRowIdentity(nil),
+	}
+}
+
+// follow makes the stream wait for more data at the end of the file
+// rather than finishing.
+func (s *rowStream) follow(tail *tailReader) {
+	s.tail = tail
+	tail.onIdle = s.flushPending
+}
+
+// readBatch reads up to n rows; the error is io.EOF once the source is exhausted.
+func (s *rowStream) readBatch(n int) ([]renderedRow, error) {
+	s.pending = make([]renderedRow, 0, n)
+	for len(s.pending) < n {
+		rec, err := s.src.Read()
+		if err != nil {
+			rows := s.pending
+			s.pending = nil
+			return rows, err
+		}
+		s.next++
+		row := renderedRow{
+			cols:          rec,
+			height:        1,
+			originalIndex: s.next,
+			flagged:       sourceRepaired(s.src),
+		}
+		s.ids.assign(&row)
+		if note := sourceNote(s.src); !note.empty() {
+			note.id = row.id
+			s.notes = append(s.notes, note)
+		}
+		s.pending = append(s.pending, row)
+	}
+	rows := s.pending
+	s.pending = nil
+	return rows, nil
+}
+
+// flushPending hands over whatever part of the current batch has been read.
+// The tail reader calls it when it catches up so new lines show up straight
+// away instead of waiting for a full batch.
+func (s *rowStream) flushPending() {
+	if len(s.pending) == 0 && s.idled {
+		return
+	}
+	s.idled = true
+	s.batches <- rowBatchMsg{rows: s.pending, columns: s.takeColumns(), issues: s.takeIssues(), notes: s.takeNotes(), read: s.counter.Count(), idle: true}
+	s.pending = make([]renderedRow, 0, cap(s.pending))
+}
+
+// run streams the remaining rows until the source is exhausted or fails.
+func (s *rowStream) run() {
+	defer close(s.batches)
+	if s.closer != nil {
+		defer s.closer.Close()
+	}
+	if s.tail != nil {
+		s.tail.following.Store(true)
+	}
+	for {
+		rows, err := s.readBatch(loadBatchSize)
+		msg := rowBatchMsg{rows: rows, columns: s.takeColumns(), issues: s.takeIssues(), notes: s.takeNotes(), read: s.counter.Count()}
+		if err != nil {
+			msg.done = true
+			if err != io.EOF {
+				msg.err = fmt.Errorf("row %d: %w", s.next+1, err)
+			}
+		}
+		s.batches <- msg
+		if msg.done {
+			return
+		}
+	}
+}
+
+func (s *rowStream) takeIssues() []loadIssue {
+	return sourceIssues(s.src)
+}
+
+func (s *rowStream) takeNotes() []rowNote {
+	notes := s.notes
+	s.notes = nil
+	return notes
+}
+
+func (s *rowStream) takeColumns() []string {
+	if cs, ok := s.src.(columnSource); ok {
+		return cs.takeColumns()
+	}
+	return nil
+}
+
+// sourceRepaired reports whether src had to repair the record it just returned.
+func sourceRepaired(src recordSource) bool {
+	is, ok := src.(issueSource)
+	return ok && is.lastRepaired()
+}
+
+// sourceIssues drains the repairs src has logged since the last call.
+func sourceIssues(src recordSource) []loadIssue {
+	if is, ok := src.(issueSource); ok {
+		return is.takeIssues()
+	}
+	return nil
+}
+
+func (s *rowStream) waitForBatch() tea.Cmd {
+	return func() tea.Msg {
+		msg, ok := <-s.batches
+		if !ok {
+			return rowBatchMsg{done: true}
+		}
+		return msg
+	}
+}
+
+// loadState tracks an in-progress background load.
+type loadState struct {
+	stream   *rowStream
+	active   bool
+	caughtUp bool // a followed file has been read to its current end
+	read     int64
+	hasData  []bool      // which columns have had content so far
+	issues   []loadIssue // lines repaired or skipped by the lenient reader
+	err      error
+}
+
+// busy reports whether rows are still arriving from the initial read.
+func (l *loadState) busy() bool {
+	return l != nil && l.active && !l.caughtUp
+}
+
+func (m *model) loadProgressLabel() string {
+	if m.load == nil || !m.load.active {
+		return ""
+	}
+	if m.load.caughtUp {
+		return "Following"
+	}
+	if m.load.stream.total > 0 {
+		pct := float64(m.load.read) * 100 / float64(m.load.stream.total)
+		return fmt.Sprintf("Loading %.0f%%", pct)
+	}
+	return fmt.Sprintf("Loading %d rows", len(m.data.rows))
+}
+
+func (m *model) handleLoadMsg(msg tea.Msg) (tea.Cmd, bool) {
+	batch, ok := msg.(rowBatchMsg)
+	if !ok || m.load == nil {
+		return nil, false
+	}
+
+	m.load.read = batch.read
+	m.load.issues = append(m.load.issues, batch.issues...)
+	clean := !m.dirty()
+	m.applyRowNotes(batch.notes)
+	if clean {
+		// annotations arriving with the rows are part of what was opened,
+		// unlike those appendRows matches from a --meta file
+		m.markSaved()
+	}
+	pinned := m.followingBottom()
+	m.addColumns(batch.columns)
+	layoutChanged := m.appendRows(batch.rows) || len(batch.columns) > 0
+	if pinned {
+		m.jumpToEnd()
+	}
+	if m.ready {
+		m.refreshView("rows-loaded", layoutChanged)
+	}
+
+	var cmd tea.Cmd
+	if batch.idle && !m.load.caughtUp {
+		m.load.caughtUp = true
+		kind := "success"
+		note, warn := m.pendingMetaSummary()
+		if warn {
+			kind = "warn"
+		}
+		cmd = m.startNotice(fmt.Sprintf("Loaded %d rows, following for more%s", len(m.data.rows), note), kind, noticeDuration)
+	}
+	if !batch.done {
+		return tea.Batch(cmd, m.load.stream.waitForBatch()), true
+	}
+
+	m.load.active = false
+	note, warn := m.pendingMetaSummary()
+	m.pendingMeta = nil
+	if batch.err != nil {
+		m.load.err = batch.err
+		return m.startNotice(fmt.Sprintf("Load stopped after %d rows: %v%s", len(m.data.rows), batch.err, note), "error", noticeDuration), true
+	}
+	if n := len(m.load.issues); n > 0 {
+		return m.startNotice(fmt.Sprintf("Loaded %d rows, %d line(s) repaired or skipped (D for details)%s", len(m.data.rows), n, note), "warn", noticeDuration), true
+	}
+	kind := "success"
+	if warn {
+		kind = "warn"
+	}
+	return m.startNotice(fmt.Sprintf("Loaded %d rows%s", len(m.data.rows), note), kind, noticeDuration), true
+}
+
+// appendRows adds freshly read rows to the model and folds them into the
+// column visibility, time bounds and current filter. It reports whether the
+// column layout needs recomputing.
+func (m *model) appendRows(rows []renderedRow) bool {
+	start := len(m.data.rows)
+	for i := range rows {
+		m.padRow(&rows[i])
+	}
+	m.data.rows = append(m.data.rows, rows...)
+	if m.pendingMeta != nil {
+		m.pendingMeta.apply(m, rows)
+	}
+
+	layoutChanged := m.trackColumnData(rows)
+	if m.data.timeColumnIndex < 0 && m.data.timeColumn == "" && start < typeSampleSize {
+		// too few rows at start-up to spot the time column
+		m.computeTimeBounds()
+	} else {
+		m.extendTimeBounds()
+	}
+	m.inferColumnTypes(rows)
+	m.extendFilter(start)
+	return layoutChanged
+}
+
+// trackColumnData hides columns that have never had content and reveals them
+// again once a later batch fills them in.
+func (m *model) trackColumnData(rows []renderedRow) bool {
+	if m.load == nil {
+		return false
+	}
+	for len(m.load.hasData) < len(m.data.header) {
+		m.load.hasData = append(m.load.hasData, false)
+	}
+	for _, row := range rows {
+		for colIdx := range m.load.hasData {
+			if !m.load.hasData[colIdx] && colIdx < len(row.cols) && strings.TrimSpace(row.cols[colIdx]) != "" {
+				m.load.hasData[colIdx] = true
+			}
+		}
+	}
+
+	changed := false
+	for i := range m.data.header {
+		col := &m.data.header[i]
+		if m.load.hasData[i] {
+			if col.Weight == 0 {
+				col.Visible = true
+				col.Weight = defaultWeightForRole(col.Role)
+				changed = true
+			}
+			continue
+		}
+		if col.Weight != 0 {
+			// Entire column is empty so far -> hide it (unless it's the primary/details column)
+			if col.Role != RolePrimary {
+				logging.Debugf("No column data yet in non-Primary column: %d so setting visibility to false", i)
+				col.Visible = false
+			}
+			col.Weight = 0
+			col.Width = 0
+			changed = true
+		}
+	}
+	return changed
+}
@@ -8,6 +8,7 @@ const (
 	rowSelectedBGColor     = "#3a3a3a"
 	searchHighlightBGColor = "#f5c542"
 	searchHighlightFGColor = "#000000"
+	rowFlaggedFGColor      = "#d7875f"
 )
 
 var (
@@ -25,6 +26,12 @@ var (
 	rowTextStyle         = lipgloss.NewStyle().Foreground(lipgloss.Color(rowTextFGColor))
 	rowSelectedTextstyle = lipgloss.NewStyle().Foreground(lipgloss.Color(rowSelectedTextFGColor))
 
+	// Row number of a line the lenient loader had to repair
+	rowFlaggedStyle = lipgloss.NewStyle().Foreground(lipgloss.Color(rowFlaggedFGColor))
+
+	// Header cell under the column cursor, the column "o" sorts by
+	headerCursorStyle = lipgloss.NewStyle().Underline(true).Bold(true)
+
 	// selectedStyle  = lipgloss.NewStyle().Background(lipgloss.Color("236")).Foreground(lipgloss.Color("254")).Padding(0, 0)
 	// markedRedStyle = lipgloss.NewStyle().Background(lipgloss.Color("124")).Foreground(lipgloss.Color("254")).Padding(0, 1)
 	cellStyle = lipgloss.NewStyle().Padding(0, 1)
@@ -19,76 +19,140 @@ const (
 
 const timeWindowResetMode = timeWindowResetDisable
 
+// computeTimeBounds picks the time column, named or detected, and parses every
+// row's timestamp from scratch.
 func (m *model) computeTimeBounds() {
-	m.data.timeColumnIndex = findTimeColumnIndex(m.data.header)
-	m.data.rowTimes = make([]time.Time, len(m.data.rows))
-	m.data.rowHasTimes = make([]bool, len(m.data.rows))
+	old := m.data.timeColumnIndex
+	if m.data.timeColumn != "" {
+		m.data.timeColumnIndex = findColumnIndex(m.data.header, m.data.timeColumn)
+	} else {
+		m.data.timeColumnIndex = m.detectTimeColumn()
+	}
+	if idx := m.data.timeColumnIndex; idx != old {
+		// the old column goes back to its own type, the new one holds times
+		oldChanged := m.reinferColumn(old)
+		if m.reinferColumn(idx) || oldChanged {
+			m.recompileFilter()
+		}
+	}
+	m.data.rowTimes = make([]time.Time, 0, len(m.data.rows))
+	m.data.rowHasTimes = make([]bool, 0, len(m.data.rows))
+	m.data.rowSeqs = make([]int64, 0, len(m.data.rows))
+	m.data.hasTimeBounds = false
+	m.extendTimeBounds()
+}
+
+// extendTimeBounds parses timestamps for rows appended since the last call and
+// widens timeMin/timeMax to cover them.
+func (m *model) extendTimeBounds() {
+	from := len(m.data.rowTimes)
+	for range m.data.rows[from:] {
+		m.data.rowTimes = append(m.data.rowTimes, time.Time{})
+		m.data.rowHasTimes = append(m.data.rowHasTimes, false)
+		m.data.rowSeqs = append(m.data.rowSeqs, -1)
+	}
 
 	if m.data.timeColumnIndex < 0 {
-		m.data.hasTimeBounds = false
 		return
 	}
 
-	hasAny := false
-	var minTime time.Time
-	var maxTime time.Time
-
-	for i, row := range m.data.rows {
+	for i := from; i < len(m.data.rows); i++ {
+		row := m.data.rows[i]
 		if m.data.timeColumnIndex >= len(row.cols) {
 			continue
 		}
 		raw := row.cols[m.data.timeColumnIndex]
-		ts, ok := parseLogTimestamp(raw)
+		ts, seq, ok := m.parseRowTimeSeq(raw)
 		if !ok {
 			continue
 		}
 		m.data.rowTimes[i] = ts
 		m.data.rowHasTimes[i] = true
-		if !hasAny {
-			minTime = ts
-			maxTime = ts
-			hasAny = true
+		m.data.rowSeqs[i] = seq
+		if !m.data.hasTimeBounds {
+			m.data.timeMin = ts
+			m.data.timeMax = ts
+			m.data.hasTimeBounds = true
 			continue
 		}
-		if ts.Before(minTime) {
-			minTime = ts
+		if ts.Before(m.data.timeMin) {
+			m.data.timeMin = ts
 		}
-		if ts.After(maxTime) {
-			maxTime = ts
+		if ts.After(m.data.timeMax) {
+			m.data.timeMax = ts
 		}
 	}
+}
+
+// minTimeColumnScore is the share of sampled values that must parse for a
+// column to be taken as the time column.
+const minTimeColumnScore = 0.8
 
-	m.data.hasTimeBounds = hasAny
-	if hasAny {
-		m.data.timeMin = minTime
-		m.data.timeMax = maxTime
+// detectTimeColumn picks the time column from the first rows loaded.
+func (m *model) detectTimeColumn() int {
+	names := make([]string, len(m.data.header))
+	for i, col := range m.data.header {
+		names[i] = col.Name
 	}
+	rows := make([][]string, len(m.data.rows))
+	for i, row := range m.data.rows {
+		rows[i] = row.cols
+	}
+	return detectTimeColumn(names, rows, func(v string) bool {
+		_, ok := m.parseRowTime(v)
+		return ok
+	})
 }
 
-func findTimeColumnIndex(cols []ColumnMeta) int {
-	for i := range cols {
-		name := strings.TrimSpace(cols[i].Name)
-		name = strings.TrimPrefix(name, "\ufeff")
-		if strings.EqualFold(name, "time") {
-			return i
+// detectTimeColumn scores each column by the share of its first
+// typeSampleSize non-empty values that parse as timestamps and returns the best, or -1 when none reaches
+// minTimeColumnScore. A column named like a time beats a better-scoring one
+// that is not, so an ID column of epoch-looking numbers does not displace
+// Timestamp. Ties go to the leftmost column.
+func detectTimeColumn(names []string, rows [][]string, parse func(string) bool) int {
+	best, bestHint, bestScore := -1, false, 0.0
+	for i, name := range names {
+		seen, parsed := 0, 0
+		for _, row := range rows {
+			if seen == typeSampleSize {
+				break
+			}
+			if i >= len(row) {
+				continue
+			}
+			v := strings.TrimSpace(row[i])
+			if v == "" {
+				continue
+			}
+			seen++
+			if parse(v) {
+				parsed++
+			}
+		}
+		if seen == 0 {
+			continue
+		}
+		score := float64(parsed) / float64(seen)
+		if score < minTimeColumnScore {
+			continue
+		}
+		hint := timeNameHint(name)
+		if best < 0 || (hint && !bestHint) || (hint == bestHint && score > bestScore) {
+			best, bestHint, bestScore = i, hint, score
 		}
 	}
-	return -1
+	return best
 }
 
-func parseLogTimestamp(raw string) (time.Time, bool) {
-	raw = strings.TrimSpace(raw)
-	if raw == "" {
-		return time.Time{}, false
-	}
-	if idx := strings.LastIndex(raw, ":"); idx != -1 {
-		raw = strings.TrimSpace(raw[:idx])
-	}
-	ts, err := time.Parse(logTimeLayout, raw)
-	if err != nil {
-		return time.Time{}, false
+// timeNameHint reports whether a column name suggests it holds timestamps.
+func timeNameHint(name string) bool {
+	name = strings.ToLower(strings.TrimSpace(name))
+	for _, key := range defaultTimeKeys {
+		if name == key {
+			return true
+		}
 	}
-	return ts, true
+	return strings.Contains(name, "time") || strings.Contains(name, "date")
 }
 
 func clampTimeToBounds(t time.Time, min time.Time, max time.Time) time.Time {
@@ -118,10 +118,10 @@ func (m *model) setTimeWindowFocus(focus int) {
 func (m *model) updateTimeWindowInputsFromDraft() {
 	tw := &m.ui.timeWindow
 	if !tw.draftStart.IsZero() {
-		tw.startInput.SetValue(tw.draftStart.Format(timeInputLayout))
+		tw.startInput.SetValue(m.formatDisplayTime(tw.draftStart))
 	}
 	if !tw.draftEnd.IsZero() {
-		tw.endInput.SetValue(tw.draftEnd.Format(timeInputLayout))
+		tw.endInput.SetValue(m.formatDisplayTime(tw.draftEnd))
 	}
 }
 
@@ -130,7 +130,7 @@ func (m *model) syncDraftFromInputs() {
 	if !m.data.hasTimeBounds {
 		return
 	}
-	loc := m.data.timeMax.Location()
+	loc := m.displayLocation()
 	startStr := strings.TrimSpace(tw.startInput.Value())
 	endStr := strings.TrimSpace(tw.endInput.Value())
 	start, err := time.ParseInLocation(timeInputLayout, startStr, loc)
@@ -156,7 +156,9 @@ func (m *model) resetTimeWindowDraft() {
 	m.updateTimeWindowInputsFromDraft()
 
 	if timeWindowResetMode == timeWindowResetDisable {
+		before := m.data.timeWindow
 		m.data.timeWindow.Enabled = false
+		m.recordWindowEdit(before)
 		m.applyFilter()
 	}
 }
@@ -166,6 +168,7 @@ func (m *model) resetTimeWindow() tea.Cmd {
 		return m.startNotice("No timestamps available", "warn", noticeDuration)
 	}
 
+	before := m.data.timeWindow
 	switch timeWindowResetMode {
 	case timeWindowResetDisable:
 		m.data.timeWindow.Enabled = false
@@ -178,6 +181,7 @@ func (m *model) resetTimeWindow() tea.Cmd {
 		}
 	}
 
+	m.recordWindowEdit(before)
 	m.applyFilter()
 	return nil
 }
@@ -191,7 +195,7 @@ func (m *model) applyTimeWindowFromInputs() {
 		return
 	}
 
-	loc := m.data.timeMax.Location()
+	loc := m.displayLocation()
 	startStr := strings.TrimSpace(tw.startInput.Value())
 	endStr := strings.TrimSpace(tw.endInput.Value())
 
@@ -217,11 +221,13 @@ func (m *model) applyTimeWindowFromInputs() {
 		return
 	}
 
+	before := m.data.timeWindow
 	m.data.timeWindow = TimeWindow{
 		Enabled: true,
 		Start:   start,
 		End:     end,
 	}
+	m.recordWindowEdit(before)
 	tw.draftStart = start
 	tw.draftEnd = end
 	m.applyFilter()
@@ -328,11 +334,13 @@ func (m *model) snapTimeWindowToStart() tea.Cmd {
 	}
 	start = clampTimeToBounds(start, m.data.timeMin, m.data.timeMax)
 	end = clampTimeToBounds(end, m.data.timeMin, m.data.timeMax)
+	before := m.data.timeWindow
 	m.data.timeWindow = TimeWindow{
 		Enabled: true,
 		Start:   start,
 		End:     end,
 	}
+	m.recordWindowEdit(before)
 	m.applyFilter()
 	return nil
 }
@@ -352,11 +360,13 @@ func (m *model) snapTimeWindowToEnd() tea.Cmd {
 	}
 	start = clampTimeToBounds(start, m.data.timeMin, m.data.timeMax)
 	end = clampTimeToBounds(end, m.data.timeMin, m.data.timeMax)
+	before := m.data.timeWindow
 	m.data.timeWindow = TimeWindow{
 		Enabled: true,
 		Start:   start,
 		End:     end,
 	}
+	m.recordWindowEdit(before)
 	m.applyFilter()
 	return nil
 }
@@ -412,7 +422,7 @@ func (m *model) timeWindowDrawerView(width int) string {
 	innerWidth := max(0, width-2)
 	lineStyle := lipgloss.NewStyle().Width(innerWidth)
 
-	startLine := fmt.Sprintf("Start: %s", tw.startInput.View())
+	startLine := fmt.Sprintf("Start: %s  [%s]", tw.startInput.View(), m.displayZoneLabel())
 	endLine := fmt.Sprintf("End:   %s", tw.endInput.View())
 	scrubberLine := m.timeWindowScrubberLine(innerWidth)
 	helpLine := fmt.Sprintf("t: open  tab: next  enter: apply  r: reset  esc: cancel  ←/→: move %s  shift+←/→: expand %s  -/+: step",
@@ -447,12 +457,12 @@ func (m *model) timeWindowScrubberLine(width int) string {
 		start, end = defaultWindowBounds(m.data.timeMin, m.data.timeMax)
 	}
 
-	minLabel := m.data.timeMin.Format(timeInputLayout)
-	maxLabel := m.data.timeMax.Format(timeInputLayout)
+	minLabel := m.formatDisplayTime(m.data.timeMin)
+	maxLabel := m.formatDisplayTime(m.data.timeMax)
 	padding := 2
 	barWidth := width - len(minLabel) - len(maxLabel) - padding*2
 	if barWidth < 10 {
-		return fmt.Sprintf("Window: %s - %s", start.Format(timeInputLayout), end.Format(timeInputLayout))
+		return fmt.Sprintf("Window: %s - %s", m.formatDisplayTime(start), m.formatDisplayTime(end))
 	}
 
 	bar := make([]rune, barWidth)
@@ -494,8 +504,44 @@ func (m *model) timeWindowStatusLabel() string {
 	if !m.data.timeWindow.Enabled {
 		return "Window: off"
 	}
-	return fmt.Sprintf("Window: %s - %s",
-		m.data.timeWindow.Start.Format(timeInputLayout),
-		m.data.timeWindow.End.Format(timeInputLayout),
+	return fmt.Sprintf("Window: %s - %s %s",
+		m.formatDisplayTime(m.data.timeWindow.Start),
+		m.formatDisplayTime(m.data.timeWindow.End),
+		m.displayZoneLabel(),
 	)
 }
+
+// currentRowSeq returns the sequence number on the selected row's timestamp, or -1.
+func (m *model) currentRowSeq() int64 {
+	if m.cursor < 0 || m.cursor >= len(m.data.filteredIndices) {
+		return -1
+	}
+	rowIdx := m.data.filteredIndices[m.cursor]
+	if rowIdx < 0 || rowIdx >= len(m.data.rowSeqs) {
+		return -1
+	}
+	return m.data.rowSeqs[rowIdx]
+}
+
+// setTimeColumn makes the named column the time column, or goes back to
+// detecting it for "auto", and re-reads every row's timestamp. A time window
+// set against the old column is switched off.
+func (m *model) setTimeColumn(name string) error {
+	if strings.EqualFold(name, "auto") {
+		m.data.timeColumn = ""
+	} else {
+		idx := findColumnIndex(m.data.header, name)
+		if idx < 0 {
+			return fmt.Errorf("no column %q", name)
+		}
+		m.data.timeColumn = m.data.header[idx].Name
+	}
+	m.data.timeLayoutHit = ""
+	m.computeTimeBounds()
+	before := m.data.timeWindow
+	m.data.timeWindow.Enabled = false
+	m.recordWindowEdit(before)
+	m.ui.timeWindow.draftStart, m.ui.timeWindow.draftEnd = time.Time{}, time.Time{}
+	m.applyFilter()
+	return nil
+}
@@ -0,0 +1,189 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Pseudo-layouts for numeric Unix timestamps.
+const (
+	layoutEpochMillis  = "epoch-ms"
+	layoutEpochSeconds = "epoch-s"
+)
+
+// defaultTimeLayouts are tried in order when neither the file nor the config
+// says otherwise.
+var defaultTimeLayouts = []string{
+	logTimeLayout,
+	time.RFC3339Nano,
+	"2006-01-02T15:04:05",
+	"2006-01-02 15:04:05",
+	time.Stamp,
+	layoutEpochMillis,
+	layoutEpochSeconds,
+}
+
+// timeLayouts is the list in use, replaced from the config file at start-up.
+var timeLayouts = defaultTimeLayouts
+
+// timeLayoutAliases lets config files and --time-layout name common formats.
+var timeLayoutAliases = map[string]string{
+	"hostlog":  logTimeLayout,
+	"iso8601":  time.RFC3339Nano,
+	"rfc3339":  time.RFC3339Nano,
+	"syslog":   time.Stamp,
+	"epoch-ms": layoutEpochMillis,
+	"epochms":  layoutEpochMillis,
+	"epoch":    layoutEpochSeconds,
+	"epoch-s":  layoutEpochSeconds,
+}
+
+// resolveTimeLayout maps an alias onto a Go layout; anything else is taken
+// to be a Go layout already.
+func resolveTimeLayout(name string) string {
+	if layout, ok := timeLayoutAliases[strings.ToLower(strings.TrimSpace(name))]; ok {
+		return layout
+	}
+	return name
+}
+
+// setTimeLayouts replaces the layouts tried for files without an override.
+func setTimeLayouts(names []string) error {
+	if len(names) == 0 {
+		return nil
+	}
+	layouts := make([]string, 0, len(names))
+	for _, name := range names {
+		layout := resolveTimeLayout(name)
+		if layout == "" {
+			return fmt.Errorf("empty time layout")
+		}
+		layouts = append(layouts, layout)
+	}
+	timeLayouts = layouts
+	return nil
+}
+
+// parseTimestamp tries each layout in turn. Host log exports append a
+// ":sequence" counter to the timestamp to order events within the same
+// second; it is split off and returned as seq (-1 when absent). Layouts
+// without a year (classic syslog) take it from ref, the time the log was
+// last written, stepping back a year for entries that would otherwise land
+// after it. Times without a zone of their own are taken to be in loc (UTC
+// when nil). hit is the layout that matched, to be tried first next time.
+func parseTimestamp(raw string, layouts []string, ref time.Time, loc *time.Location) (ts time.Time, seq int64, hit string, ok bool) {
+	raw = strings.TrimSpace(raw)
+	if raw == "" {
+		return time.Time{}, -1, "", false
+	}
+
+	if loc == nil {
+		loc = time.UTC
+	}
+	head, seq := raw, int64(-1)
+	if idx := strings.LastIndex(raw, ":"); idx > 0 {
+		if n, err := strconv.ParseInt(raw[idx+1:], 10, 64); err == nil {
+			head, seq = strings.TrimSpace(raw[:idx]), n
+		}
+	}
+	for _, layout := range layouts {
+		if ts, ok := parseWithLayout(raw, layout, ref, loc); ok {
+			return ts, -1, layout, true
+		}
+		if seq >= 0 {
+			if ts, ok := parseWithLayout(head, layout, ref, loc); ok {
+				return ts, seq, layout, true
+			}
+		}
+	}
+	return time.Time{}, -1, "", false
+}
+
+func parseWithLayout(raw, layout string, ref time.Time, loc *time.Location) (time.Time, bool) {
+	switch layout {
+	case layoutEpochMillis:
+		// 13 digits covers 2001 to 2286; shorter runs of digits are not times
+		if len(raw) != 13 {
+			return time.Time{}, false
+		}
+		n, err := strconv.ParseInt(raw, 10, 64)
+		if err != nil {
+			return time.Time{}, false
+		}
+		return time.UnixMilli(n).In(loc), true
+	case layoutEpochSeconds:
+		if len(raw) != 10 {
+			return time.Time{}, false
+		}
+		n, err := strconv.ParseInt(raw, 10, 64)
+		if err != nil {
+			return time.Time{}, false
+		}
+		return time.Unix(n, 0).In(loc), true
+	}
+
+	ts, err := time.ParseInLocation(layout, raw, loc)
+	if err != nil {
+		return time.Time{}, false
+	}
+	ts = fixZone(ts)
+	if ts.Year() == 0 {
+		if ref.IsZero() {
+			ref = time.Now()
+		}
+		ts = time.Date(ref.Year(), ts.Month(), ts.Day(), ts.Hour(), ts.Minute(), ts.Second(), ts.Nanosecond(), ts.Location())
+		if ts.After(ref.Add(24 * time.Hour)) {
+			ts = ts.AddDate(-1, 0, 0)
+		}
+	}
+	return ts, true
+}
+
+// parseRowTimeSeq parses a time cell with the file's own layout if it has
+// one, otherwise with the configured list, starting from the layout that
+// matched last time.
+func (m *model) parseRowTimeSeq(raw string) (time.Time, int64, bool) {
+	layouts := timeLayouts
+	if m.data.timeLayout != "" {
+		layouts = []string{m.data.timeLayout}
+	} else if m.data.timeLayoutHit != "" && m.data.timeLayoutHit != layouts[0] {
+		layouts = append([]string{m.data.timeLayoutHit}, layouts...)
+	}
+	ts, seq, hit, ok := parseTimestamp(raw, layouts, m.data.timeRef, m.data.sourceZone)
+	if ok {
+		m.data.timeLayoutHit = hit
+	}
+	return ts, seq, ok
+}
+
+// parseRowTime parses a time cell, dropping any sequence number.
+func (m *model) parseRowTime(raw string) (time.Time, bool) {
+	ts, _, ok := m.parseRowTimeSeq(raw)
+	return ts, ok
+}
+
+// compareRowTimes orders two rows by timestamp, then by sequence number.
+// Rows without a time sort after those with one.
+func (m *model) compareRowTimes(a, b int) int {
+	ha, hb := m.data.rowHasTimes[a], m.data.rowHasTimes[b]
+	switch {
+	case ha && !hb:
+		return -1
+	case !ha && hb:
+		return 1
+	case !ha && !hb:
+		return 0
+	}
+	if c := m.data.rowTimes[a].Compare(m.data.rowTimes[b]); c != 0 {
+		return c
+	}
+	if m.data.rowSeqs[a] < m.data.rowSeqs[b] {
+		return -1
+	}
+	if m.data.rowSeqs[a] > m.data.rowSeqs[b] {
+		return 1
+	}
+	return 0
+}
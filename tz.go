@@ -0,0 +1,162 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// zoneOffsets maps the zone abbreviations seen in host logs onto their UTC
+// offset in seconds. time.Parse only knows the abbreviations of the local
+// zone and invents a zero offset for the rest, which puts BST rows an hour
+// out. Ambiguous names take their most common meaning (CST is US Central).
+var zoneOffsets = map[string]int{
+	"UTC":  0,
+	"GMT":  0,
+	"Z":    0,
+	"WET":  0,
+	"WEST": 1 * 3600,
+	"BST":  1 * 3600,
+	"IST":  1 * 3600, // Irish Standard Time, as written by Dublin hosts
+	"CET":  1 * 3600,
+	"CEST": 2 * 3600,
+	"MET":  1 * 3600,
+	"MEST": 2 * 3600,
+	"EET":  2 * 3600,
+	"EEST": 3 * 3600,
+	"MSK":  3 * 3600,
+	"EST":  -5 * 3600,
+	"EDT":  -4 * 3600,
+	"CST":  -6 * 3600,
+	"CDT":  -5 * 3600,
+	"MST":  -7 * 3600,
+	"MDT":  -6 * 3600,
+	"PST":  -8 * 3600,
+	"PDT":  -7 * 3600,
+	"AKST": -9 * 3600,
+	"AKDT": -8 * 3600,
+	"HST":  -10 * 3600,
+	"JST":  9 * 3600,
+	"KST":  9 * 3600,
+	"AEST": 10 * 3600,
+	"AEDT": 11 * 3600,
+	"ACST": 9*3600 + 1800,
+	"ACDT": 10*3600 + 1800,
+	"AWST": 8 * 3600,
+	"NZST": 12 * 3600,
+	"NZDT": 13 * 3600,
+}
+
+// fixZone corrects a time parsed from a zone abbreviation the location did
+// not know. Times already carrying the right offset are returned unchanged.
+func fixZone(ts time.Time) time.Time {
+	name, off := ts.Zone()
+	want, ok := zoneOffsets[strings.ToUpper(name)]
+	if !ok || want == off {
+		return ts
+	}
+	return time.Date(ts.Year(), ts.Month(), ts.Day(), ts.Hour(), ts.Minute(), ts.Second(), ts.Nanosecond(), time.FixedZone(name, want))
+}
+
+// parseZone accepts an IANA name (Europe/London), "UTC", "Local", or one of
+// the abbreviations in zoneOffsets.
+func parseZone(name string) (*time.Location, error) {
+	name = strings.TrimSpace(name)
+	switch strings.ToLower(name) {
+	case "":
+		return nil, fmt.Errorf("empty time zone")
+	case "local":
+		return time.Local, nil
+	case "utc":
+		return time.UTC, nil
+	}
+	if off, ok := zoneOffsets[strings.ToUpper(name)]; ok {
+		return time.FixedZone(strings.ToUpper(name), off), nil
+	}
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		return nil, fmt.Errorf("unknown time zone %q", name)
+	}
+	return loc, nil
+}
+
+// displayZone selects the zone timestamps are shown and typed in.
+type displayZone int
+
+const (
+	displayZoneSource displayZone = iota // as written in the file
+	displayZoneUTC
+	displayZoneLocal
+)
+
+func (z displayZone) String() string {
+	switch z {
+	case displayZoneUTC:
+		return "UTC"
+	case displayZoneLocal:
+		return "local"
+	}
+	return "source"
+}
+
+// displayLocation is the zone used for time window inputs and labels.
+func (m *model) displayLocation() *time.Location {
+	switch m.ui.displayZone {
+	case displayZoneUTC:
+		return time.UTC
+	case displayZoneLocal:
+		return time.Local
+	}
+	if m.data.sourceZone != nil {
+		return m.data.sourceZone
+	}
+	if m.data.hasTimeBounds {
+		return m.data.timeMax.Location()
+	}
+	return time.UTC
+}
+
+// displayZoneLabel names the zone shown, e.g. "source (BST)".
+func (m *model) displayZoneLabel() string {
+	if m.ui.displayZone == displayZoneLocal {
+		return "local (" + time.Now().In(time.Local).Format("MST") + ")"
+	}
+	if m.ui.displayZone == displayZoneUTC {
+		return "UTC"
+	}
+	name := m.data.sourceZoneName
+	if name == "" && m.data.hasTimeBounds {
+		name, _ = m.data.timeMax.Zone()
+	}
+	if name == "" {
+		return "source"
+	}
+	return "source (" + name + ")"
+}
+
+// cycleDisplayZone steps source → UTC → local → source.
+func (m *model) cycleDisplayZone() tea.Cmd {
+	m.ui.displayZone = (m.ui.displayZone + 1) % 3
+	m.updateTimeWindowInputsFromDraft()
+	return m.startNotice("Times shown in "+m.displayZoneLabel(), "info", noticeDuration)
+}
+
+// formatDisplayTime renders a timestamp in the display zone.
+func (m *model) formatDisplayTime(ts time.Time) string {
+	return ts.In(m.displayLocation()).Format(timeInputLayout)
+}
+
+// displayTimeCell is what the time column shows for a row: the raw cell in
+// source mode, otherwise the parsed time converted to the display zone.
+func (m *model) displayTimeCell(rowIdx int, raw string) string {
+	if m.ui.displayZone == displayZoneSource || rowIdx < 0 || rowIdx >= len(m.data.rowHasTimes) || !m.data.rowHasTimes[rowIdx] {
+		return raw
+	}
+	s := m.data.rowTimes[rowIdx].In(m.displayLocation()).Format(timeInputLayout + " MST")
+	if seq := m.data.rowSeqs[rowIdx]; seq >= 0 {
+		s += fmt.Sprintf(":%d", seq)
+	}
+	return s
+}
@@ -13,12 +13,15 @@ type footerState struct {
 	ModeInput string
 
 	FileName string
+	Dirty    bool // unsaved changes
 
 	FilterLabel string
 	MarksOnly   bool
 
 	Row       int
 	TotalRows int
+	Progress  string
+	Seq       int64 // sequence number of the selected row's timestamp, -1 if none
 
 	StatusMessage string
 	Legend        string
@@ -79,6 +82,12 @@ func renderControlBar(width int, st footerState, styles footerStyles) string {
 	statusFixedW := runeWidth(fmt.Sprintf("[FILTER: %s] · [MARKS ONLY: %s]", strings.Repeat("X", filterValW), strings.Repeat("X", marksW)))
 
 	rightPlain := fmt.Sprintf(" Rows %d/%d", st.Row, st.TotalRows)
+	if st.Seq >= 0 {
+		rightPlain = fmt.Sprintf(" Seq %d ·%s", st.Seq, rightPlain)
+	}
+	if st.Progress != "" {
+		rightPlain = fmt.Sprintf(" %s ·%s", st.Progress, rightPlain)
+	}
 	rightPlain = truncatePlain(rightPlain, width)
 	rightW := runeWidth(rightPlain)
 
@@ -175,6 +184,9 @@ func renderFileSegment(colW int, st footerState, styles footerStyles) string {
 	if name == "" {
 		name = "(no file)"
 	}
+	if st.Dirty {
+		name = "[+] " + name
+	}
 	innerW := max(0, colW-2)
 	inner := truncatePlain(name, innerW)
 	filePlain := inner
@@ -15,4 +15,6 @@ type uiState struct {
 	debugHeightFree         int
 	debugDesiredAboveHeight int
 	timeWindow              timeWindowUI
+	displayZone             displayZone // zone timestamps are shown and typed in
+	colCursor               int         // header index of the column under the column cursor
 }
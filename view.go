@@ -18,12 +18,19 @@ func (m *model) headerView() string {
 
 	var cells []string
 
-	for _, col := range m.data.header {
+	for i, col := range m.data.header {
 		if !col.Visible || col.Width <= 0 {
 			continue
 		}
 
-		cell := cellStyle.Width(col.Width).Render(col.Name)
+		style := cellStyle.Width(col.Width)
+		if col.Type.numeric() {
+			style = style.Align(lipgloss.Right)
+		}
+		if i == m.ui.colCursor {
+			style = style.Inherit(headerCursorStyle)
+		}
+		cell := style.Render(m.headerLabel(col))
 		cells = append(cells, cell)
 	}
 
@@ -70,10 +77,13 @@ func (m *model) footerView(width int) string {
 		ModeInput:     modeInput,
 		FileName:      defaultSaveName(*m),
 		FilterLabel:   "None",
+		Dirty:         m.dirty(),
 		MarksOnly:     m.data.showOnlyMarked,
 		Row:           m.cursor + 1,
 		TotalRows:     len(m.data.filteredIndices),
 		StatusMessage: "",
+		Progress:      m.loadProgressLabel(),
+		Seq:           m.currentRowSeq(),
 		Legend:        "(? help · f filter · / search · t time window · T reset window · > start · < end · c edit comment · v view comments)",
 	}
 	if m.data.filterPattern != "" {
@@ -148,6 +158,9 @@ func (m *model) renderRowAt(filteredIdx int) (string, int, bool) {
 
 	_, commentPresent := m.data.commentRows[row.id]
 	standardMarker := m.getRowMarker(row.id)
+	if row.flagged {
+		rowBgStyle = rowBgStyle.Inherit(rowFlaggedStyle)
+	}
 
 	// figure out how wide the row number gutter needs to be
 	markerWidth := len(fmt.Sprintf("%d", len(m.data.rows))) + utf8.RuneCountInString(commentMarker)
@@ -161,9 +174,13 @@ func (m *model) renderRowAt(filteredIdx int) (string, int, bool) {
 	}
 
 	contentRow := row
+	if tc := m.data.timeColumnIndex; m.ui.displayZone != displayZoneSource && tc >= 0 && tc < len(row.cols) {
+		contentRow.cols = append([]string(nil), row.cols...)
+		contentRow.cols[tc] = m.displayTimeCell(rowIdx, row.cols[tc])
+	}
 	if m.ui.searchQuery != "" {
-		cols := make([]string, len(row.cols))
-		for i, col := range row.cols {
+		cols := make([]string, len(contentRow.cols))
+		for i, col := range contentRow.cols {
 			cols[i] = highlightMatches(col, m.ui.searchQuery)
 		}
 		contentRow.cols = cols